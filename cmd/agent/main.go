@@ -19,13 +19,17 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/yolocs/knative-policy-binding/pkg/agent"
+	"github.com/yolocs/knative-policy-binding/pkg/agent/extauthz"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	pkglogging "knative.dev/pkg/logging"
 	pkgnet "knative.dev/pkg/network"
 	"knative.dev/pkg/signals"
@@ -36,6 +40,16 @@ type config struct {
 	PolicyPath         string `envconfig:"POLICY_PATH" required:"true"`
 	AgentLoggingConfig string `envconfig:"AGENT_LOGGING_CONFIG" required:"true"`
 	AgentLoggingLevel  string `envconfig:"AGENT_LOGGING_LEVEL" required:"true"`
+
+	// ExtAuthzPort is the port to serve Envoy's ext_authz gRPC API on.
+	// The gRPC server is disabled if it's not set.
+	ExtAuthzPort int `envconfig:"EXT_AUTHZ_PORT"`
+
+	// ProxyPort is the port the enforcing reverse proxy listens on and
+	// ProxyTarget is where allowed requests are forwarded to.
+	// The proxy is disabled if ProxyPort is not set.
+	ProxyPort   int    `envconfig:"PROXY_PORT"`
+	ProxyTarget string `envconfig:"PROXY_TARGET"`
 }
 
 var logger *zap.SugaredLogger
@@ -63,7 +77,16 @@ func main() {
 		"decision-server": pkgnet.NewServer(":"+strconv.Itoa(env.AgentPort), decider),
 	}
 
-	errCh := make(chan error, len(servers))
+	if env.ProxyPort != 0 {
+		target, err := url.Parse(env.ProxyTarget)
+		if err != nil || target.Host == "" {
+			fmt.Fprintf(os.Stderr, "Invalid proxy target %q: %v", env.ProxyTarget, err)
+			os.Exit(1)
+		}
+		servers["proxy-server"] = pkgnet.NewServer(":"+strconv.Itoa(env.ProxyPort), agent.NewProxy(ctx, decider, target))
+	}
+
+	errCh := make(chan error, len(servers)+1)
 	for name, server := range servers {
 		go func(name string, s *http.Server) {
 			logger.Infof("Starting server %q...", name)
@@ -73,6 +96,23 @@ func main() {
 		}(name, server)
 	}
 
+	var grpcServer *grpc.Server
+	if env.ExtAuthzPort != 0 {
+		lis, err := net.Listen("tcp", ":"+strconv.Itoa(env.ExtAuthzPort))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to listen on ext_authz port: %v", err)
+			os.Exit(1)
+		}
+		grpcServer = grpc.NewServer()
+		extauthz.NewServer(decider).Register(grpcServer)
+		go func() {
+			logger.Info("Starting server \"ext-authz-server\"...")
+			if err := grpcServer.Serve(lis); err != nil {
+				errCh <- fmt.Errorf("ext-authz server failed: %w", err)
+			}
+		}()
+	}
+
 	// Blocks until we actually receive a TERM signal or one of the servers
 	// exit unexpectedly. We fold both signals together because we only want
 	// to act on the first of those to reach here.
@@ -90,6 +130,9 @@ func main() {
 				logger.Errorw("Failed to shutdown server", zap.String("server", serverName), zap.Error(err))
 			}
 		}
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
 	}
 }
 
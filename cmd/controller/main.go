@@ -17,20 +17,39 @@ limitations under the License.
 package main
 
 import (
-	// The set of controllers this controller process runs.
+	"os"
+	"strconv"
 
+	// The set of controllers this controller process runs.
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler/httppolicybinding"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/istiobinding"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler/migration"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/opabinding"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/policypsbinding"
 
 	// This defines the shared main for injected controllers.
+	"knative.dev/pkg/injection"
 	"knative.dev/pkg/injection/sharedmain"
 )
 
+// migrateEnv enables migrating the v1alpha1 bindings to HTTPPolicyBindings.
+// The v1alpha1 CRDs must be installed.
+const migrateEnv = "MIGRATE_V1ALPHA1"
+
 func main() {
-	sharedmain.Main("controller",
-		istiobinding.NewController,
-		opabinding.NewController,
+	ctors := []injection.ControllerConstructor{
+		httppolicybinding.NewController(httppolicybinding.Classes{
+			security.IstioBindingClass: istiobinding.NewClassReconciler,
+			security.OPABindingClass:   opabinding.NewClassReconciler,
+		}),
 		policypsbinding.NewController,
-	)
+	}
+	if migrate, _ := strconv.ParseBool(os.Getenv(migrateEnv)); migrate {
+		ctors = append(ctors,
+			migration.NewAuthorizableBindingController,
+			migration.NewPolicyBindingController,
+		)
+	}
+	sharedmain.Main("controller", ctors...)
 }
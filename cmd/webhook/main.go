@@ -19,8 +19,12 @@ package main
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection/sharedmain"
@@ -31,10 +35,18 @@ import (
 	"knative.dev/pkg/webhook/certificates"
 	"knative.dev/pkg/webhook/configmaps"
 	"knative.dev/pkg/webhook/resourcesemantics"
+	"knative.dev/pkg/webhook/resourcesemantics/conversion"
 	"knative.dev/pkg/webhook/resourcesemantics/defaulting"
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
 
+	"github.com/yolocs/knative-policy-binding/pkg/apis/config"
+	securityv1alpha1 "github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha1"
 	securityv1alpha2 "github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	securityscheme "github.com/yolocs/knative-policy-binding/pkg/client/clientset/versioned/scheme"
+	clusterpolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/clusterhttppolicy"
+	policyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicy"
+	bindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicybinding"
+	securitylisters "github.com/yolocs/knative-policy-binding/pkg/client/listers/security/v1alpha2"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/policypsbinding"
 	"github.com/yolocs/knative-policy-binding/pkg/webhook/psbinding"
 )
@@ -42,11 +54,17 @@ import (
 var securityTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
 	// List the types to validate.
 	securityv1alpha2.SchemeGroupVersion.WithKind("HTTPPolicy"):               &securityv1alpha2.HTTPPolicy{},
+	securityv1alpha2.SchemeGroupVersion.WithKind("ClusterHTTPPolicy"):        &securityv1alpha2.ClusterHTTPPolicy{},
+	securityv1alpha2.SchemeGroupVersion.WithKind("EventPolicy"):              &securityv1alpha2.EventPolicy{},
 	securityv1alpha2.SchemeGroupVersion.WithKind("HTTPPolicyBinding"):        &securityv1alpha2.HTTPPolicyBinding{},
 	securityv1alpha2.SchemeGroupVersion.WithKind("PolicyPodspecableBinding"): &securityv1alpha2.PolicyPodspecableBinding{},
 }
 
 func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	// Decorate contexts with the current state of the config.
+	store := config.NewStore(logging.FromContext(ctx).Named("config-store"))
+	store.WatchConfigs(cmw)
+
 	return defaulting.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
@@ -59,11 +77,7 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 		securityTypes,
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
-		},
+		store.ToContext,
 
 		// Whether to disallow unknown fields.
 		true,
@@ -71,6 +85,12 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 }
 
 func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	lister := &bindingLister{
+		bindings:        bindinginformer.Get(ctx).Lister(),
+		policies:        policyinformer.Get(ctx).Lister(),
+		clusterPolicies: clusterpolicyinformer.Get(ctx).Lister(),
+	}
+
 	return validation.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
@@ -84,9 +104,8 @@ func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
 		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
+			// Bindings and policies are validated against each other.
+			return securityv1alpha2.WithBindingLister(ctx, lister)
 		},
 
 		// Whether to disallow unknown fields.
@@ -94,6 +113,67 @@ func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher
 	)
 }
 
+// bindingLister looks up HTTPPolicyBindings and policies in the informer
+// caches.
+type bindingLister struct {
+	bindings        securitylisters.HTTPPolicyBindingLister
+	policies        securitylisters.HTTPPolicyLister
+	clusterPolicies securitylisters.ClusterHTTPPolicyLister
+}
+
+func (l *bindingLister) ListBindings(namespace string) ([]*securityv1alpha2.HTTPPolicyBinding, error) {
+	if namespace == "" {
+		return l.bindings.List(labels.Everything())
+	}
+	return l.bindings.HTTPPolicyBindings(namespace).List(labels.Everything())
+}
+
+func (l *bindingLister) GetPolicySpec(ref corev1.ObjectReference) (*securityv1alpha2.HTTPPolicySpec, error) {
+	var spec *securityv1alpha2.HTTPPolicySpec
+	var err error
+	switch ref.Kind {
+	case "", "HTTPPolicy":
+		var p *securityv1alpha2.HTTPPolicy
+		if p, err = l.policies.HTTPPolicies(ref.Namespace).Get(ref.Name); err == nil {
+			spec = &p.Spec
+		}
+	case "ClusterHTTPPolicy":
+		var p *securityv1alpha2.ClusterHTTPPolicy
+		if p, err = l.clusterPolicies.Get(ref.Name); err == nil {
+			spec = &p.Spec
+		}
+	}
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	}
+	return spec, err
+}
+
+func NewConversionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	return conversion.NewConversionController(ctx,
+
+		// The path on which to serve the webhook.
+		"/resource-conversion",
+
+		// Specify the types of custom resource definitions that should be converted.
+		map[schema.GroupKind]conversion.GroupKindConversion{
+			securityv1alpha2.Kind("EventPolicy"): {
+				DefinitionName: "eventpolicies.security.knative.dev",
+				HubVersion:     securityv1alpha1.SchemeGroupVersion.Version,
+				Zygotes: map[string]conversion.ConvertibleObject{
+					securityv1alpha1.SchemeGroupVersion.Version: &securityv1alpha1.EventPolicy{},
+					securityv1alpha2.SchemeGroupVersion.Version: &securityv1alpha2.EventPolicy{},
+				},
+			},
+		},
+
+		// A function that infuses the context passed to ConvertUp/ConvertDown/SetDefaults with custom metadata.
+		func(ctx context.Context) context.Context {
+			return ctx
+		},
+	)
+}
+
 func NewConfigValidationController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 	return configmaps.NewAdmissionController(ctx,
 
@@ -105,8 +185,9 @@ func NewConfigValidationController(ctx context.Context, cmw configmap.Watcher) *
 
 		// The configmaps to validate.
 		configmap.Constructors{
-			logging.ConfigMapName(): logging.NewConfigFromConfigMap,
-			metrics.ConfigMapName(): metrics.NewObservabilityConfigFromConfigMap,
+			logging.ConfigMapName():  logging.NewConfigFromConfigMap,
+			metrics.ConfigMapName():  metrics.NewObservabilityConfigFromConfigMap,
+			config.BindingConfigName: config.NewBindingFromConfigMap,
 		},
 	)
 }
@@ -131,6 +212,9 @@ func NewPolicyBindingWebhook(ctx context.Context, cmw configmap.Watcher) *contro
 }
 
 func main() {
+	// The psbinding webhook records events about the bindings.
+	securityscheme.AddToScheme(scheme.Scheme)
+
 	ctx := webhook.WithOptions(signals.NewContext(), webhook.Options{
 		ServiceName: "webhook",
 		Port:        8443,
@@ -141,6 +225,7 @@ func main() {
 		certificates.NewController,
 		NewDefaultingAdmissionController,
 		NewValidationAdmissionController,
+		NewConversionController,
 		NewConfigValidationController,
 		NewPolicyBindingWebhook,
 	)
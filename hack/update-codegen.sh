@@ -45,14 +45,14 @@ ${KNATIVE_CODEGEN_PKG}/hack/generate-knative.sh "injection" \
   --go-header-file ${REPO_ROOT}/hack/boilerplate/boilerplate.go.txt
 
 # Generate our own client for istio (otherwise injection won't work)
-${CODEGEN_PKG}/generate-groups.sh "client,informer,lister" \
-  github.com/yolocs/knative-policy-binding/pkg/client/istio istio.io/client-go/pkg/apis \
+${CODEGEN_PKG}/generate-groups.sh "deepcopy,client,informer,lister" \
+  github.com/yolocs/knative-policy-binding/pkg/client/istio github.com/yolocs/knative-policy-binding/pkg/apis/istio \
   "security:v1beta1" \
   --go-header-file ${REPO_ROOT}/hack/boilerplate/boilerplate.go.txt
 
 # Knative Injection (for istio)
 ${KNATIVE_CODEGEN_PKG}/hack/generate-knative.sh "injection" \
-  github.com/yolocs/knative-policy-binding/pkg/client/istio istio.io/client-go/pkg/apis \
+  github.com/yolocs/knative-policy-binding/pkg/client/istio github.com/yolocs/knative-policy-binding/pkg/apis/istio \
   "security:v1beta1" \
   --go-header-file ${REPO_ROOT}/hack/boilerplate/boilerplate.go.txt
 
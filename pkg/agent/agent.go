@@ -22,15 +22,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
-	"time"
+	"path/filepath"
+	"sync/atomic"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
 	"go.uber.org/zap"
 	"knative.dev/pkg/logging"
+
+	"github.com/yolocs/knative-policy-binding/pkg/opa"
 )
 
+// Decider makes decisions for requests based on the policy file.
 type Decider struct {
 	cache  *cachedQuery
 	logger *zap.SugaredLogger
@@ -38,28 +41,32 @@ type Decider struct {
 
 type cachedQuery struct {
 	policyPath string
-	query      rego.PreparedEvalQuery
+	// current holds the *compiledPolicy in use. It's swapped atomically
+	// whenever the policy file changes.
+	current atomic.Value
+}
+
+type compiledPolicy struct {
+	revision string
+	query    rego.PreparedEvalQuery
 }
 
 func (c *cachedQuery) start(ctx context.Context) error {
 	if err := c.load(ctx); err != nil {
 		return err
 	}
-	go func() {
-		for {
-			select {
-			case <-time.After(5 * time.Second):
-				if err := c.load(ctx); err != nil {
-					logging.FromContext(ctx).Errorf("%v", err)
-				} else {
-					logging.FromContext(ctx).Debugf("file %q refreshed", c.policyPath)
-				}
-			case <-ctx.Done():
-				return
-			}
+	// Watch the directory rather than the file, mounted ConfigMaps are
+	// updated by swapping symlinks in it.
+	return watchDir(ctx, filepath.Dir(c.policyPath), func() {
+		if err := c.load(ctx); err != nil {
+			logging.FromContext(ctx).Errorf("%v", err)
 		}
-	}()
-	return nil
+	})
+}
+
+func (c *cachedQuery) get() *compiledPolicy {
+	cp, _ := c.current.Load().(*compiledPolicy)
+	return cp
 }
 
 func (c *cachedQuery) load(ctx context.Context) error {
@@ -69,6 +76,11 @@ func (c *cachedQuery) load(ctx context.Context) error {
 	}
 
 	module := string(b)
+	revision := opa.Revision(module)
+	if cur := c.get(); cur != nil && cur.revision == revision {
+		return nil
+	}
+
 	compiler, err := ast.CompileModules(map[string]string{
 		"policy": module,
 	})
@@ -77,7 +89,7 @@ func (c *cachedQuery) load(ctx context.Context) error {
 	}
 
 	r := rego.New(
-		rego.Query("data.security.knative.dev.allow"),
+		rego.Query(policyPackage),
 		rego.Compiler(compiler),
 	)
 
@@ -86,7 +98,8 @@ func (c *cachedQuery) load(ctx context.Context) error {
 		return fmt.Errorf("failed to prepare for eval: %w", err)
 	}
 
-	c.query = pq
+	c.current.Store(&compiledPolicy{revision: revision, query: pq})
+	logging.FromContext(ctx).Infof("Loaded policy revision %q from %q", revision, c.policyPath)
 	return nil
 }
 
@@ -104,6 +117,11 @@ func NewDecider(ctx context.Context, policyPath string) (*Decider, error) {
 func (d *Decider) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	d.logger.Debug("Received decision request")
 
+	if req.Method == http.MethodGet {
+		d.servePolicyStatus(w)
+		return
+	}
+
 	if req.Method != http.MethodPost {
 		w.WriteHeader(http.StatusBadRequest)
 		return
@@ -123,9 +141,7 @@ func (d *Decider) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	dresp := &DecisionResponse{
-		Allow: d.isAllowed(req.Context(), dreq),
-	}
+	dresp := d.Decide(req.Context(), dreq)
 
 	respBytes, err := json.Marshal(dresp)
 	if err != nil {
@@ -137,21 +153,52 @@ func (d *Decider) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	w.Write(respBytes)
 }
 
-func (d *Decider) isAllowed(ctx context.Context, dr DecisionRequest) bool {
-	d.logger.Debugf("Evaluating input: %v", *dr.HTTPRequest)
+// Decide evaluates the decision request against the current policy.
+// It always fails close.
+func (d *Decider) Decide(ctx context.Context, dr DecisionRequest) *DecisionResponse {
+	if dr.HTTPRequest != nil {
+		d.logger.Debugf("Evaluating input: %v", *dr.HTTPRequest)
+	}
 
-	rs, err := d.cache.query.Eval(ctx, rego.EvalInput(dr))
+	policy := d.cache.get()
+	rs, err := policy.query.Eval(ctx, rego.EvalInput(dr))
 	if err != nil {
 		d.logger.Warnw("failed to evaluate input and will fail-close", zap.Error(err))
-		return false
+		return &DecisionResponse{Reason: ReasonEvaluationError, Message: err.Error(), PolicyRevision: policy.revision}
 	}
 
 	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
-		return false
+		return &DecisionResponse{Reason: ReasonUndefinedDecision, PolicyRevision: policy.revision}
 	}
 
 	d.logger.Debugf("Eval result: %v", rs[0])
 
-	// Super hacky
-	return strings.Contains(fmt.Sprintf("%v", rs[0]), "true")
+	doc, ok := rs[0].Expressions[0].Value.(map[string]interface{})
+	if !ok {
+		return &DecisionResponse{Reason: ReasonUndefinedDecision, PolicyRevision: policy.revision}
+	}
+	resp := decisionFromDocument(doc)
+	resp.PolicyRevision = policy.revision
+	if len(resp.AuditedRules) > 0 {
+		d.logger.Infow("Request matched audit rules",
+			zap.Strings("auditedRules", resp.AuditedRules),
+			zap.Bool("allow", resp.Allow),
+			zap.Any("httpRequest", dr.HTTPRequest))
+	}
+	return resp
+}
+
+// Revision returns the revision of the policy currently enforced.
+func (d *Decider) Revision() string {
+	return d.cache.get().revision
+}
+
+func (d *Decider) servePolicyStatus(w http.ResponseWriter) {
+	b, err := json.Marshal(&PolicyStatus{Revision: d.Revision()})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
 }
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// ParseBody parses a JSON request body for the policy to look at. The data of
+// structured CloudEvents encoded in base64 is decoded if it's JSON, so that
+// the policy can match its fields. Bodies that are not JSON are ignored.
+func ParseBody(contentType string, b []byte) interface{} {
+	if len(b) == 0 || !strings.Contains(contentType, "json") {
+		return nil
+	}
+	var body interface{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil
+	}
+	if !strings.Contains(contentType, "cloudevents") {
+		return body
+	}
+
+	switch v := body.(type) {
+	case map[string]interface{}:
+		decodeEventData(v)
+	case []interface{}:
+		// A batch of events.
+		for _, e := range v {
+			if m, ok := e.(map[string]interface{}); ok {
+				decodeEventData(m)
+			}
+		}
+	}
+	return body
+}
+
+// decodeEventData replaces the base64 data of a structured event with the JSON
+// it encodes. The data is "data_base64" since v1.0, and "data" along with the
+// "base64" content encoding before.
+func decodeEventData(e map[string]interface{}) {
+	if ct, _ := e["datacontenttype"].(string); !strings.Contains(ct, "json") {
+		return
+	}
+	encoded, ok := e["data_base64"].(string)
+	if !ok {
+		if enc, _ := e["datacontentencoding"].(string); enc != "base64" {
+			return
+		}
+		if encoded, ok = e["data"].(string); !ok {
+			return
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return
+	}
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return
+	}
+	e["data"] = data
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/yolocs/knative-policy-binding/pkg/agent"
+)
+
+const (
+	defaultTimeout = 2 * time.Second
+	retryBackoff   = 50 * time.Millisecond
+)
+
+// DecisionClient calls the policy decider.
+type DecisionClient struct {
+	url        string
+	httpClient *http.Client
+	retries    int
+	failOpen   bool
+}
+
+// Option configures the DecisionClient.
+type Option func(*DecisionClient)
+
+// WithTimeout sets the timeout of a single decision request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *DecisionClient) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries sets how many times a failed decision request is retried.
+func WithRetries(retries int) Option {
+	return func(c *DecisionClient) {
+		c.retries = retries
+	}
+}
+
+// WithFailOpen allows requests when the decider can't be reached.
+// By default the client fails close.
+func WithFailOpen() Option {
+	return func(c *DecisionClient) {
+		c.failOpen = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client to call the decider with.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *DecisionClient) {
+		c.httpClient = hc
+	}
+}
+
+// NewDecisionClient creates a DecisionClient for the decider at url.
+func NewDecisionClient(url string, opts ...Option) *DecisionClient {
+	c := &DecisionClient{
+		url:        url,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Decide asks the decider for a decision. If the decider can't be reached
+// after all retries, the returned decision follows the failure policy and
+// the error is returned alongside it.
+func (c *DecisionClient) Decide(ctx context.Context, dr *agent.DecisionRequest) (*agent.DecisionResponse, error) {
+	b, err := json.Marshal(dr)
+	if err != nil {
+		return c.failed(), fmt.Errorf("failed to marshal decision request: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		var dresp *agent.DecisionResponse
+		dresp, err = c.send(ctx, b)
+		if err == nil {
+			return dresp, nil
+		}
+		if attempt >= c.retries {
+			break
+		}
+		select {
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		case <-ctx.Done():
+			return c.failed(), ctx.Err()
+		}
+	}
+	return c.failed(), err
+}
+
+func (c *DecisionClient) send(ctx context.Context, b []byte) (*agent.DecisionResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decision request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send decision request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("decision server error: %d", resp.StatusCode)
+	}
+
+	rb, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decision response: %w", err)
+	}
+	var dresp agent.DecisionResponse
+	if err := json.Unmarshal(rb, &dresp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decision response: %w", err)
+	}
+	return &dresp, nil
+}
+
+func (c *DecisionClient) failed() *agent.DecisionResponse {
+	return &agent.DecisionResponse{
+		Allow:  c.failOpen,
+		Reason: agent.ReasonDeciderUnavailable,
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"github.com/yolocs/knative-policy-binding/pkg/agent"
+)
+
+type envConfig struct {
+	DeciderURL   string `envconfig:"K_POLICY_DECIDER"`
+	CheckPayload bool   `envconfig:"K_POLICY_CHECK_PAYLOAD"`
+}
+
+// NewMiddlewareFromEnv wraps next with policy enforcement configured by the
+// env vars injected by the policy binding. If K_POLICY_DECIDER is not set,
+// next is returned as is.
+func NewMiddlewareFromEnv(next http.Handler, opts ...Option) (http.Handler, error) {
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		return nil, err
+	}
+	if env.DeciderURL == "" {
+		return next, nil
+	}
+	return NewMiddleware(NewDecisionClient(env.DeciderURL, opts...), env.CheckPayload, next), nil
+}
+
+// NewMiddleware wraps next so that only requests allowed by the decider reach
+// it. If checkPayload is true, JSON request bodies are sent to the decider,
+// and the ones too large or invalid to be checked are rejected.
+func NewMiddleware(c *DecisionClient, checkPayload bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := logging.FromContext(req.Context())
+		partial := agent.NewPartialHTTPRequest(req)
+		if checkPayload {
+			body, err := agent.PeekBody(req)
+			if err != nil {
+				logger.Warnw("Failed to read request body", zap.Error(err))
+				agent.WritePayloadError(w, err)
+				return
+			}
+			partial.Body = body
+		}
+
+		dresp, err := c.Decide(req.Context(), &agent.DecisionRequest{
+			Protocol:    "http",
+			HTTPRequest: partial,
+		})
+		if err != nil {
+			logger.Errorw("Failed to get decision", zap.Error(err))
+		}
+
+		if !dresp.Allow {
+			dresp.WriteDenied(w)
+			return
+		}
+		for k, vs := range dresp.Headers {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+const (
+	// The policy package evaluated for every decision.
+	policyPackage = "data.security.knative.dev"
+
+	// Well-known documents in the policy package. Only "allow" is required,
+	// the others let a policy explain its decision to the caller.
+	allowDoc      = "allow"
+	matchedDoc    = "matched"
+	deniedDoc     = "denied"
+	auditedDoc    = "audited"
+	reasonDoc     = "reason"
+	messageDoc    = "message"
+	headersDoc    = "headers"
+	statusCodeDoc = "status_code"
+)
+
+// WriteDenied writes a denied response with the headers and status code
+// suggested by the policy.
+func (r *DecisionResponse) WriteDenied(w http.ResponseWriter) {
+	for k, vs := range r.Headers {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	code := r.StatusCode
+	if code == 0 {
+		code = http.StatusForbidden
+	}
+	w.WriteHeader(code)
+	w.Write([]byte(r.Reason))
+}
+
+// decisionFromDocument builds a DecisionResponse from the evaluated policy
+// package document.
+func decisionFromDocument(doc map[string]interface{}) *DecisionResponse {
+	resp := &DecisionResponse{}
+	resp.Allow, _ = doc[allowDoc].(bool)
+	resp.MatchedRules = append(toStrings(doc[matchedDoc]), toStrings(doc[deniedDoc])...)
+	sort.Strings(resp.MatchedRules)
+	resp.AuditedRules = toStrings(doc[auditedDoc])
+	sort.Strings(resp.AuditedRules)
+	resp.Reason, _ = doc[reasonDoc].(string)
+	resp.Message, _ = doc[messageDoc].(string)
+
+	if hs, ok := doc[headersDoc].(map[string]interface{}); ok {
+		resp.Headers = make(http.Header, len(hs))
+		for k, v := range hs {
+			if s, ok := v.(string); ok {
+				resp.Headers.Add(k, s)
+				continue
+			}
+			for _, s := range toStrings(v) {
+				resp.Headers.Add(k, s)
+			}
+		}
+	}
+
+	if n, ok := doc[statusCodeDoc].(json.Number); ok {
+		if code, err := n.Int64(); err == nil {
+			resp.StatusCode = int(code)
+		}
+	}
+
+	if !resp.Allow && resp.Reason == "" {
+		resp.Reason = ReasonNoRuleMatched
+	}
+	return resp
+}
+
+// toStrings converts a rego set or array of strings to a string slice.
+func toStrings(v interface{}) []string {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	var ret []string
+	for _, e := range arr {
+		if s, ok := e.(string); ok {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extauthz
+
+import (
+	"github.com/golang/protobuf/proto"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+)
+
+// The messages below are wire compatible with the subset of
+// envoy.service.auth.v3 that the agent needs. Field numbers follow
+// https://github.com/envoyproxy/envoy/tree/master/api/envoy/service/auth/v3
+// Unknown fields are dropped while decoding and oneof members are modeled as
+// plain optional fields, which is identical on the wire.
+
+// CheckRequest is envoy.service.auth.v3.CheckRequest.
+type CheckRequest struct {
+	Attributes *AttributeContext `protobuf:"bytes,1,opt,name=attributes,proto3" json:"attributes,omitempty"`
+}
+
+// AttributeContext is envoy.service.auth.v3.AttributeContext.
+type AttributeContext struct {
+	Source            *Peer             `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Destination       *Peer             `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	Request           *Request          `protobuf:"bytes,4,opt,name=request,proto3" json:"request,omitempty"`
+	ContextExtensions map[string]string `protobuf:"bytes,10,rep,name=context_extensions,json=contextExtensions,proto3" json:"context_extensions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+// Peer is envoy.service.auth.v3.AttributeContext.Peer.
+type Peer struct {
+	Address   *Address          `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Service   string            `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Labels    map[string]string `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Principal string            `protobuf:"bytes,4,opt,name=principal,proto3" json:"principal,omitempty"`
+}
+
+// Address is envoy.config.core.v3.Address.
+type Address struct {
+	SocketAddress *SocketAddress `protobuf:"bytes,1,opt,name=socket_address,json=socketAddress,proto3" json:"socket_address,omitempty"`
+}
+
+// SocketAddress is envoy.config.core.v3.SocketAddress.
+type SocketAddress struct {
+	Address   string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	PortValue uint32 `protobuf:"varint,3,opt,name=port_value,json=portValue,proto3" json:"port_value,omitempty"`
+}
+
+// Request is envoy.service.auth.v3.AttributeContext.Request.
+type Request struct {
+	HTTP *HTTPRequest `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"`
+}
+
+// HTTPRequest is envoy.service.auth.v3.AttributeContext.HttpRequest.
+type HTTPRequest struct {
+	ID       string            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Method   string            `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	Headers  map[string]string `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Path     string            `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	Host     string            `protobuf:"bytes,5,opt,name=host,proto3" json:"host,omitempty"`
+	Scheme   string            `protobuf:"bytes,6,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	Query    string            `protobuf:"bytes,7,opt,name=query,proto3" json:"query,omitempty"`
+	Fragment string            `protobuf:"bytes,8,opt,name=fragment,proto3" json:"fragment,omitempty"`
+	Size     int64             `protobuf:"varint,9,opt,name=size,proto3" json:"size,omitempty"`
+	Protocol string            `protobuf:"bytes,10,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Body     string            `protobuf:"bytes,11,opt,name=body,proto3" json:"body,omitempty"`
+	RawBody  []byte            `protobuf:"bytes,12,opt,name=raw_body,json=rawBody,proto3" json:"raw_body,omitempty"`
+}
+
+// CheckResponse is envoy.service.auth.v3.CheckResponse.
+type CheckResponse struct {
+	Status         *rpcstatus.Status   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	DeniedResponse *DeniedHTTPResponse `protobuf:"bytes,2,opt,name=denied_response,json=deniedResponse,proto3" json:"denied_response,omitempty"`
+	OkResponse     *OkHTTPResponse     `protobuf:"bytes,3,opt,name=ok_response,json=okResponse,proto3" json:"ok_response,omitempty"`
+}
+
+// DeniedHTTPResponse is envoy.service.auth.v3.DeniedHttpResponse.
+type DeniedHTTPResponse struct {
+	Status  *HTTPStatus          `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Headers []*HeaderValueOption `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`
+	Body    string               `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+// OkHTTPResponse is envoy.service.auth.v3.OkHttpResponse.
+type OkHTTPResponse struct {
+	Headers         []*HeaderValueOption `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`
+	HeadersToRemove []string             `protobuf:"bytes,5,rep,name=headers_to_remove,json=headersToRemove,proto3" json:"headers_to_remove,omitempty"`
+}
+
+// HTTPStatus is envoy.type.v3.HttpStatus.
+type HTTPStatus struct {
+	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+// HeaderValueOption is envoy.config.core.v3.HeaderValueOption.
+type HeaderValueOption struct {
+	Header *HeaderValue `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+}
+
+// HeaderValue is envoy.config.core.v3.HeaderValue.
+type HeaderValue struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *CheckRequest) Reset()         { *m = CheckRequest{} }
+func (m *CheckRequest) String() string { return proto.CompactTextString(m) }
+func (*CheckRequest) ProtoMessage()    {}
+
+func (m *AttributeContext) Reset()         { *m = AttributeContext{} }
+func (m *AttributeContext) String() string { return proto.CompactTextString(m) }
+func (*AttributeContext) ProtoMessage()    {}
+
+func (m *Peer) Reset()         { *m = Peer{} }
+func (m *Peer) String() string { return proto.CompactTextString(m) }
+func (*Peer) ProtoMessage()    {}
+
+func (m *Address) Reset()         { *m = Address{} }
+func (m *Address) String() string { return proto.CompactTextString(m) }
+func (*Address) ProtoMessage()    {}
+
+func (m *SocketAddress) Reset()         { *m = SocketAddress{} }
+func (m *SocketAddress) String() string { return proto.CompactTextString(m) }
+func (*SocketAddress) ProtoMessage()    {}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *HTTPRequest) Reset()         { *m = HTTPRequest{} }
+func (m *HTTPRequest) String() string { return proto.CompactTextString(m) }
+func (*HTTPRequest) ProtoMessage()    {}
+
+func (m *CheckResponse) Reset()         { *m = CheckResponse{} }
+func (m *CheckResponse) String() string { return proto.CompactTextString(m) }
+func (*CheckResponse) ProtoMessage()    {}
+
+func (m *DeniedHTTPResponse) Reset()         { *m = DeniedHTTPResponse{} }
+func (m *DeniedHTTPResponse) String() string { return proto.CompactTextString(m) }
+func (*DeniedHTTPResponse) ProtoMessage()    {}
+
+func (m *OkHTTPResponse) Reset()         { *m = OkHTTPResponse{} }
+func (m *OkHTTPResponse) String() string { return proto.CompactTextString(m) }
+func (*OkHTTPResponse) ProtoMessage()    {}
+
+func (m *HTTPStatus) Reset()         { *m = HTTPStatus{} }
+func (m *HTTPStatus) String() string { return proto.CompactTextString(m) }
+func (*HTTPStatus) ProtoMessage()    {}
+
+func (m *HeaderValueOption) Reset()         { *m = HeaderValueOption{} }
+func (m *HeaderValueOption) String() string { return proto.CompactTextString(m) }
+func (*HeaderValueOption) ProtoMessage()    {}
+
+func (m *HeaderValue) Reset()         { *m = HeaderValue{} }
+func (m *HeaderValue) String() string { return proto.CompactTextString(m) }
+func (*HeaderValue) ProtoMessage()    {}
@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extauthz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/yolocs/knative-policy-binding/pkg/agent"
+)
+
+const serviceName = "envoy.service.auth.v3.Authorization"
+
+// Decider makes policy decisions for decision requests.
+type Decider interface {
+	Decide(ctx context.Context, dr agent.DecisionRequest) *agent.DecisionResponse
+}
+
+// Server implements Envoy's external authorization gRPC API on top of
+// a Decider.
+type Server struct {
+	decider Decider
+}
+
+// NewServer creates a new ext_authz server.
+func NewServer(d Decider) *Server {
+	return &Server{decider: d}
+}
+
+// Register registers the Authorization service with the gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+// Check implements envoy.service.auth.v3.Authorization/Check.
+func (s *Server) Check(ctx context.Context, req *CheckRequest) (*CheckResponse, error) {
+	dresp := s.decider.Decide(ctx, toDecisionRequest(req))
+
+	headers := toHeaderValueOptions(dresp.Headers)
+	if dresp.Allow {
+		return &CheckResponse{
+			Status:     &rpcstatus.Status{Code: int32(codes.OK)},
+			OkResponse: &OkHTTPResponse{Headers: headers},
+		}, nil
+	}
+
+	code := dresp.StatusCode
+	if code == 0 {
+		code = http.StatusForbidden
+	}
+	return &CheckResponse{
+		Status: &rpcstatus.Status{
+			Code:    int32(codes.PermissionDenied),
+			Message: dresp.Reason,
+		},
+		DeniedResponse: &DeniedHTTPResponse{
+			Status:  &HTTPStatus{Code: int32(code)},
+			Headers: headers,
+			Body:    dresp.Reason,
+		},
+	}, nil
+}
+
+func toDecisionRequest(req *CheckRequest) agent.DecisionRequest {
+	dr := agent.DecisionRequest{Protocol: "http"}
+	attrs := req.Attributes
+	if attrs == nil {
+		return dr
+	}
+
+	if attrs.Source != nil {
+		dr.Source.Identity = attrs.Source.Principal
+	}
+
+	partial := &agent.PartialHTTPRequest{
+		Header: http.Header{},
+	}
+	if attrs.Source != nil && attrs.Source.Address != nil && attrs.Source.Address.SocketAddress != nil {
+		sa := attrs.Source.Address.SocketAddress
+		partial.RemoteAddr = net.JoinHostPort(sa.Address, strconv.Itoa(int(sa.PortValue)))
+	}
+	if attrs.Request != nil && attrs.Request.HTTP != nil {
+		hr := attrs.Request.HTTP
+		partial.Method = hr.Method
+		partial.Host = hr.Host
+		// Envoy reports the path with the query string.
+		partial.Path = strings.SplitN(hr.Path, "?", 2)[0]
+		if hr.Size > 0 {
+			partial.ContentLength = hr.Size
+		}
+		for k, v := range hr.Headers {
+			// Skip HTTP/2 pseudo headers, they're already mapped above.
+			if strings.HasPrefix(k, ":") {
+				continue
+			}
+			partial.Header.Add(k, v)
+		}
+		partial.Body = agent.ParseBody(partial.Header.Get("Content-Type"), []byte(hr.Body))
+	}
+	dr.HTTPRequest = partial
+	return dr
+}
+
+func toHeaderValueOptions(h http.Header) []*HeaderValueOption {
+	var ret []*HeaderValueOption
+	for k, vs := range h {
+		for _, v := range vs {
+			ret = append(ret, &HeaderValueOption{
+				Header: &HeaderValue{Key: k, Value: v},
+			})
+		}
+	}
+	return ret
+}
+
+// authorizationServer is the service interface for the grpc.ServiceDesc.
+type authorizationServer interface {
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+}
+
+func checkHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(authorizationServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/Check",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(authorizationServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*authorizationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    checkHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "envoy/service/auth/v3/external_auth.proto",
+}
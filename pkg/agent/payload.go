@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// MaxPayloadBytes caps how much of a request body is read for the policy.
+const MaxPayloadBytes = 1 << 20
+
+var (
+	// ErrPayloadTooLarge and ErrInvalidPayload reject the JSON bodies the
+	// policy can't check the payload rules against.
+	ErrPayloadTooLarge = errors.New("request body is too large to be checked")
+	ErrInvalidPayload  = errors.New("request body is not valid JSON")
+)
+
+// PeekBody parses a JSON request body and leaves the request body intact for
+// the next handler. Non-JSON bodies are ignored, but JSON bodies too large or
+// invalid to be parsed are errors.
+func PeekBody(req *http.Request) (interface{}, error) {
+	if req.Body == nil || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(req.Body, MaxPayloadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(b), req.Body))
+	if len(b) > MaxPayloadBytes {
+		return nil, ErrPayloadTooLarge
+	}
+	if len(b) > 0 && !json.Valid(b) {
+		return nil, ErrInvalidPayload
+	}
+	return ParseBody(req.Header.Get("Content-Type"), b), nil
+}
+
+// WritePayloadError rejects a request whose body PeekBody failed on.
+func WritePayloadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrPayloadTooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.WriteHeader(http.StatusBadRequest)
+}
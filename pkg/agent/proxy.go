@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// Proxy is a reverse proxy in front of the user container. It only forwards
+// requests that are allowed by the policy. JSON bodies are checked by the
+// policy too, so the ones too large or invalid to be checked are rejected.
+type Proxy struct {
+	decider *Decider
+	proxy   *httputil.ReverseProxy
+	logger  *zap.SugaredLogger
+}
+
+// NewProxy creates a Proxy forwarding allowed requests to target.
+func NewProxy(ctx context.Context, d *Decider, target *url.URL) *Proxy {
+	return &Proxy{
+		decider: d,
+		proxy:   httputil.NewSingleHostReverseProxy(target),
+		logger:  logging.FromContext(ctx),
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	partial := NewPartialHTTPRequest(req)
+	body, err := PeekBody(req)
+	if err != nil {
+		p.logger.Warnw("Failed to read request body", zap.Error(err))
+		WritePayloadError(w, err)
+		return
+	}
+	partial.Body = body
+
+	dresp := p.decider.Decide(req.Context(), DecisionRequest{
+		Protocol:    "http",
+		HTTPRequest: partial,
+	})
+
+	if !dresp.Allow {
+		p.logger.Debugw("Request denied", zap.String("reason", dresp.Reason), zap.String("path", req.URL.Path))
+		dresp.WriteDenied(w)
+		return
+	}
+
+	for k, vs := range dresp.Headers {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	p.proxy.ServeHTTP(w, req)
+}
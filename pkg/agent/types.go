@@ -31,15 +31,60 @@ type Source struct {
 }
 
 type PartialHTTPRequest struct {
-	Method        string                 `json:"method,omitempty"`
-	Host          string                 `json:"host,omitempty"`
-	Path          string                 `json:"path,omitempty"`
-	Header        http.Header            `json:"header,omitempty"`
-	ContentLength int64                  `json:"contentLength,omitempty"`
-	RemoteAddr    string                 `json:"remoteAddr,omitempty"`
-	Body          map[string]interface{} `json:"body,omitempty"`
+	Method        string      `json:"method,omitempty"`
+	Host          string      `json:"host,omitempty"`
+	Path          string      `json:"path,omitempty"`
+	Header        http.Header `json:"header,omitempty"`
+	ContentLength int64       `json:"contentLength,omitempty"`
+	RemoteAddr    string      `json:"remoteAddr,omitempty"`
+	Body          interface{} `json:"body,omitempty"`
+}
+
+// NewPartialHTTPRequest captures the parts of the request the policy looks at.
+// The body is not included.
+func NewPartialHTTPRequest(req *http.Request) *PartialHTTPRequest {
+	return &PartialHTTPRequest{
+		Method:        req.Method,
+		Host:          req.Host,
+		Path:          req.URL.Path,
+		Header:        req.Header,
+		ContentLength: req.ContentLength,
+		RemoteAddr:    req.RemoteAddr,
+	}
 }
 
 type DecisionResponse struct {
 	Allow bool `json:"allow"`
+	// MatchedRules are the names of the policy rules that matched the request.
+	MatchedRules []string `json:"matchedRules,omitempty"`
+	// AuditedRules are the names of the AUDIT rules that matched the request.
+	AuditedRules []string `json:"auditedRules,omitempty"`
+	// Reason is a machine-readable reason why the request was denied.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable explanation of the decision.
+	Message string `json:"message,omitempty"`
+	// Headers are the headers the caller should add to its response.
+	Headers http.Header `json:"headers,omitempty"`
+	// StatusCode is the status code the caller should respond with when the
+	// request is denied. Zero means the caller picks (usually 403).
+	StatusCode int `json:"statusCode,omitempty"`
+	// PolicyRevision is the revision of the policy that made the decision.
+	PolicyRevision string `json:"policyRevision,omitempty"`
 }
+
+// PolicyStatus is returned by the decider for GET requests.
+type PolicyStatus struct {
+	// Revision is the revision of the policy currently enforced.
+	Revision string `json:"revision"`
+}
+
+const (
+	// ReasonNoRuleMatched means none of the policy rules allowed the request.
+	ReasonNoRuleMatched = "NoRuleMatched"
+	// ReasonEvaluationError means the policy failed to evaluate.
+	ReasonEvaluationError = "EvaluationError"
+	// ReasonUndefinedDecision means the policy didn't produce a decision.
+	ReasonUndefinedDecision = "UndefinedDecision"
+	// ReasonDeciderUnavailable means the decider couldn't be reached.
+	ReasonDeciderUnavailable = "DeciderUnavailable"
+)
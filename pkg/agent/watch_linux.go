@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Changes in the directory that may change the content of a file in it.
+// Mounted ConfigMaps are updated by atomically swapping the "..data" symlink,
+// which shows up as a move into the directory.
+const watchMask = unix.IN_CREATE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM |
+	unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB
+
+// watchDir calls onChange whenever something changes in dir until the context
+// is done.
+func watchDir(ctx context.Context, dir string, onChange func()) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to init inotify: %w", err)
+	}
+	if _, err := unix.InotifyAddWatch(fd, dir, watchMask); err != nil {
+		unix.Close(fd)
+		return fmt.Errorf("failed to watch %q: %w", dir, err)
+	}
+
+	// The non-blocking fd is registered with the runtime poller so closing
+	// the file unblocks the pending read.
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	go func() {
+		buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+		for {
+			// A single read may return a batch of events, one reload is
+			// enough for all of them.
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+			onChange()
+		}
+	}()
+	return nil
+}
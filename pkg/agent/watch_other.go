@@ -0,0 +1,41 @@
+//go:build !linux
+// +build !linux
+
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+// watchDir falls back to polling where inotify is not available. Reloading
+// an unchanged policy is cheap since it's skipped by content hash.
+func watchDir(ctx context.Context, dir string, onChange func()) error {
+	go func() {
+		for {
+			select {
+			case <-time.After(5 * time.Second):
+				onChange()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
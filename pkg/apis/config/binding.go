@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+)
+
+const (
+	// BindingConfigName is the name of the ConfigMap with the binding
+	// defaults.
+	BindingConfigName = "config-binding"
+
+	// BindingDefaultsConfigKey is the key of the binding defaults in the
+	// ConfigMap.
+	BindingDefaultsConfigKey = "default-binding-config"
+)
+
+// Binding holds the defaults of HTTPPolicyBindings, for the whole cluster
+// and for specific namespaces.
+type Binding struct {
+	// ClusterDefault applies to the namespaces without their own defaults.
+	ClusterDefault *BindingDefaults `json:"clusterDefault,omitempty"`
+	// NamespaceDefaults are the defaults of specific namespaces.
+	NamespaceDefaults map[string]*BindingDefaults `json:"namespaceDefaults,omitempty"`
+}
+
+// BindingDefaults are the defaults of HTTPPolicyBindings.
+type BindingDefaults struct {
+	// BindingClass is the class of the bindings without one.
+	BindingClass string `json:"bindingClass,omitempty"`
+}
+
+// NewBindingFromConfigMap creates a Binding from the supplied ConfigMap.
+func NewBindingFromConfigMap(config *corev1.ConfigMap) (*Binding, error) {
+	b := &Binding{}
+	raw, ok := config.Data[BindingDefaultsConfigKey]
+	if !ok {
+		return b, nil
+	}
+	if err := yaml.Unmarshal([]byte(raw), b); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", BindingDefaultsConfigKey, err)
+	}
+	if err := b.ClusterDefault.validate(); err != nil {
+		return nil, fmt.Errorf("invalid clusterDefault: %w", err)
+	}
+	for ns, d := range b.NamespaceDefaults {
+		if err := d.validate(); err != nil {
+			return nil, fmt.Errorf("invalid namespaceDefaults for %q: %w", ns, err)
+		}
+	}
+	return b, nil
+}
+
+func (d *BindingDefaults) validate() error {
+	if d == nil || d.BindingClass == "" {
+		return nil
+	}
+	if !security.IsBindingClassRegistered(d.BindingClass) {
+		return fmt.Errorf("unknown binding class %q, expecting one of %v", d.BindingClass, security.BindingClasses())
+	}
+	return nil
+}
+
+// DefaultBindingClass returns the class of the bindings without one in the
+// namespace. It's empty if there is no default.
+func (b *Binding) DefaultBindingClass(namespace string) string {
+	if d, ok := b.NamespaceDefaults[namespace]; ok && d != nil && d.BindingClass != "" {
+		return d.BindingClass
+	}
+	if b.ClusterDefault != nil {
+		return b.ClusterDefault.BindingClass
+	}
+	return ""
+}
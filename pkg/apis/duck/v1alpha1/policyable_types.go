@@ -41,6 +41,8 @@ type Policyable struct {
 
 // PolicyableSpec contains the spec of a Policyable object.
 type PolicyableSpec struct {
+	// CheckPayload sends JSON request bodies to the decider, e.g. to match
+	// structured CloudEvents.
 	CheckPayload bool `json:"checkPayload,omitempty"`
 }
 
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AuthorizationPolicy enables access control on workloads.
+type AuthorizationPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AuthorizationPolicySpec `json:"spec,omitempty"`
+}
+
+// AuthorizationPolicyAction is the action to take when a rule matches.
+type AuthorizationPolicyAction string
+
+const (
+	AuthorizationPolicyActionAllow AuthorizationPolicyAction = "ALLOW"
+	AuthorizationPolicyActionDeny  AuthorizationPolicyAction = "DENY"
+	AuthorizationPolicyActionAudit AuthorizationPolicyAction = "AUDIT"
+)
+
+type AuthorizationPolicySpec struct {
+	// Selector selects the workloads the policy applies to.
+	Selector *WorkloadSelector `json:"selector,omitempty"`
+	// Rules to match requests. A request matches if any rule matches.
+	Rules []*Rule `json:"rules,omitempty"`
+	// Action defaults to ALLOW.
+	Action AuthorizationPolicyAction `json:"action,omitempty"`
+}
+
+// WorkloadSelector selects workloads by labels.
+type WorkloadSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// Rule matches requests from a list of sources that perform a list of
+// operations subject to a list of conditions.
+type Rule struct {
+	From []*RuleFrom  `json:"from,omitempty"`
+	To   []*RuleTo    `json:"to,omitempty"`
+	When []*Condition `json:"when,omitempty"`
+}
+
+type RuleFrom struct {
+	Source *Source `json:"source,omitempty"`
+}
+
+type RuleTo struct {
+	Operation *Operation `json:"operation,omitempty"`
+}
+
+// Source specifies the source identities of a request.
+type Source struct {
+	Principals           []string `json:"principals,omitempty"`
+	NotPrincipals        []string `json:"notPrincipals,omitempty"`
+	RequestPrincipals    []string `json:"requestPrincipals,omitempty"`
+	NotRequestPrincipals []string `json:"notRequestPrincipals,omitempty"`
+	Namespaces           []string `json:"namespaces,omitempty"`
+	NotNamespaces        []string `json:"notNamespaces,omitempty"`
+	IPBlocks             []string `json:"ipBlocks,omitempty"`
+	NotIPBlocks          []string `json:"notIpBlocks,omitempty"`
+}
+
+// Operation specifies the operations of a request.
+type Operation struct {
+	Hosts      []string `json:"hosts,omitempty"`
+	NotHosts   []string `json:"notHosts,omitempty"`
+	Ports      []string `json:"ports,omitempty"`
+	NotPorts   []string `json:"notPorts,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+	NotMethods []string `json:"notMethods,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+	NotPaths   []string `json:"notPaths,omitempty"`
+}
+
+// Condition specifies additional required attributes.
+type Condition struct {
+	Key       string   `json:"key"`
+	Values    []string `json:"values,omitempty"`
+	NotValues []string `json:"notValues,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AuthorizationPolicyList is a collection of AuthorizationPolicies.
+type AuthorizationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuthorizationPolicy `json:"items"`
+}
@@ -0,0 +1,22 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1beta1 mirrors the security.istio.io/v1beta1 API of Istio 1.5.
+// The vendored Istio client predates RequestAuthentication and
+// AuthorizationPolicy actions, so the types are maintained here instead.
+// +k8s:deepcopy-gen=package
+// +groupName=security.istio.io
+package v1beta1
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RequestAuthentication defines what request authentication methods are
+// supported by a workload.
+type RequestAuthentication struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RequestAuthenticationSpec `json:"spec,omitempty"`
+}
+
+type RequestAuthenticationSpec struct {
+	// Selector selects the workloads the policy applies to.
+	Selector *WorkloadSelector `json:"selector,omitempty"`
+	// JWTRules define how to verify JWT.
+	JWTRules []*JWTRule `json:"jwtRules,omitempty"`
+}
+
+// JWTRule describes how to verify JWT from an issuer.
+type JWTRule struct {
+	Issuer                string       `json:"issuer"`
+	Audiences             []string     `json:"audiences,omitempty"`
+	JwksURI               string       `json:"jwksUri,omitempty"`
+	Jwks                  string       `json:"jwks,omitempty"`
+	FromHeaders           []*JWTHeader `json:"fromHeaders,omitempty"`
+	FromParams            []string     `json:"fromParams,omitempty"`
+	OutputPayloadToHeader string       `json:"outputPayloadToHeader,omitempty"`
+	ForwardOriginalToken  bool         `json:"forwardOriginalToken,omitempty"`
+}
+
+// JWTHeader is a header location to extract JWT from.
+type JWTHeader struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RequestAuthenticationList is a collection of RequestAuthentications.
+type RequestAuthenticationList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RequestAuthentication `json:"items"`
+}
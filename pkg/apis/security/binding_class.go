@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	// BindingClassAnnotationKey selects the implementation enforcing an
+	// HTTPPolicyBinding.
+	BindingClassAnnotationKey = GroupName + "/binding.class"
+
+	// IstioBindingClass enforces bindings with Istio AuthorizationPolicies.
+	IstioBindingClass = "istio"
+	// OPABindingClass enforces bindings with OPA agents injected into the
+	// workloads.
+	OPABindingClass = "opa"
+)
+
+var (
+	bindingClassesMu sync.RWMutex
+	bindingClasses   = map[string]struct{}{
+		IstioBindingClass: {},
+		OPABindingClass:   {},
+	}
+)
+
+// RegisterBindingClass makes the binding class valid. Implementations of
+// other classes register them in the webhook before it starts.
+func RegisterBindingClass(class string) {
+	bindingClassesMu.Lock()
+	defer bindingClassesMu.Unlock()
+	bindingClasses[class] = struct{}{}
+}
+
+// IsBindingClassRegistered tells if the binding class is valid.
+func IsBindingClassRegistered(class string) bool {
+	bindingClassesMu.RLock()
+	defer bindingClassesMu.RUnlock()
+	_, ok := bindingClasses[class]
+	return ok
+}
+
+// BindingClasses returns the valid binding classes, sorted.
+func BindingClasses() []string {
+	bindingClassesMu.RLock()
+	defer bindingClassesMu.RUnlock()
+	classes := make([]string, 0, len(bindingClasses))
+	for c := range bindingClasses {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	return classes
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+const (
+	// EnforcementModeAnnotationKey selects how the agents of the opa binding
+	// class enforce an HTTPPolicyBinding. By default the application is
+	// expected to call the decider of the agent itself.
+	EnforcementModeAnnotationKey = GroupName + "/enforcement.mode"
+	// EnforcementModeProxy makes the agent front the user container as a
+	// reverse proxy. The user container is moved to another port through the
+	// PORT env and should only listen on localhost, otherwise it is still
+	// reachable on the pod IP. Knative Services can't be proxied, as Knative
+	// sets PORT itself.
+	EnforcementModeProxy = "proxy"
+	// EnforcementModeExtAuthz declares that the mesh has Envoy check the
+	// requests with the ext_authz API of the agent (port grpc-ext-authz).
+	// Unlike the other modes, it gives the agent the peer identity, which
+	// rules on source namespaces and principals need.
+	EnforcementModeExtAuthz = "ext-authz"
+)
@@ -0,0 +1,235 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+)
+
+const (
+	// EventPolicySpecAnnotationKey keeps the v1alpha1 spec of an EventPolicy
+	// that v1alpha2 can't fully express, so that reading it as v1alpha1
+	// restores it.
+	EventPolicySpecAnnotationKey = "security.knative.dev/v1alpha1-spec"
+
+	// EventPolicyV1alpha2SpecAnnotationKey keeps the v1alpha2 spec of an
+	// EventPolicy that v1alpha1 can't fully express, so that reading it as
+	// v1alpha2 restores it.
+	EventPolicyV1alpha2SpecAnnotationKey = "security.knative.dev/v1alpha2-spec"
+)
+
+// ConvertUp implements apis.Convertible. The v1alpha2 spec kept by
+// ConvertDown is restored, and the lossy v1alpha1 view of it can't be changed:
+// the annotation has to be removed to replace it. Rule groups v1alpha2 can't
+// match exactly, e.g. with contains or data rules, are dropped, which fails
+// closed. So do the structured events matched with CheckPayload.
+func (p *EventPolicy) ConvertUp(ctx context.Context, to apis.Convertible) error {
+	switch sink := to.(type) {
+	case *v1alpha2.EventPolicy:
+		sink.ObjectMeta = *p.ObjectMeta.DeepCopy()
+		deleteAnnotations(&sink.ObjectMeta, EventPolicyV1alpha2SpecAnnotationKey)
+		if kept, ok := p.Annotations[EventPolicyV1alpha2SpecAnnotationKey]; ok {
+			var spec v1alpha2.EventPolicySpec
+			if err := json.Unmarshal([]byte(kept), &spec); err != nil {
+				return fmt.Errorf("failed to parse annotation %s: %w", EventPolicyV1alpha2SpecAnnotationKey, err)
+			}
+			var converted EventPolicySpec
+			converted.convertDown(&spec)
+			if !equality.Semantic.DeepEqual(converted, p.Spec) {
+				return fmt.Errorf("the v1alpha2 rules of the EventPolicy can't be changed as v1alpha1, "+
+					"update it as v1alpha2 or remove annotation %s", EventPolicyV1alpha2SpecAnnotationKey)
+			}
+			sink.Spec = spec
+			return nil
+		}
+		spec, lossless := p.Spec.convertUp(ctx)
+		sink.Spec = spec
+		if lossless {
+			deleteAnnotations(&sink.ObjectMeta, EventPolicySpecAnnotationKey)
+			return nil
+		}
+		b, err := json.Marshal(p.Spec)
+		if err != nil {
+			return err
+		}
+		if sink.Annotations == nil {
+			sink.Annotations = make(map[string]string)
+		}
+		sink.Annotations[EventPolicySpecAnnotationKey] = string(b)
+		return nil
+	default:
+		return fmt.Errorf("unknown version, got: %T", sink)
+	}
+}
+
+// ConvertDown implements apis.Convertible. The v1alpha1 spec kept by ConvertUp
+// is restored unless the v1alpha2 spec changed since. v1alpha2 rules v1alpha1
+// can't express are dropped, which fails closed. The v1alpha2 spec is then
+// kept in an annotation, which ConvertUp restores instead of the lossy spec.
+func (p *EventPolicy) ConvertDown(ctx context.Context, from apis.Convertible) error {
+	switch source := from.(type) {
+	case *v1alpha2.EventPolicy:
+		p.ObjectMeta = *source.ObjectMeta.DeepCopy()
+		deleteAnnotations(&p.ObjectMeta, EventPolicySpecAnnotationKey, EventPolicyV1alpha2SpecAnnotationKey)
+		if kept, ok := source.Annotations[EventPolicySpecAnnotationKey]; ok {
+			var spec EventPolicySpec
+			if err := json.Unmarshal([]byte(kept), &spec); err == nil {
+				if converted, _ := spec.convertUp(ctx); equality.Semantic.DeepEqual(converted, source.Spec) {
+					p.Spec = spec
+					return nil
+				}
+			}
+		}
+		p.Spec.convertDown(&source.Spec)
+		if converted, _ := p.Spec.convertUp(ctx); equality.Semantic.DeepEqual(converted, source.Spec) {
+			return nil
+		}
+		b, err := json.Marshal(source.Spec)
+		if err != nil {
+			return err
+		}
+		if p.Annotations == nil {
+			p.Annotations = make(map[string]string)
+		}
+		p.Annotations[EventPolicyV1alpha2SpecAnnotationKey] = string(b)
+		return nil
+	default:
+		return fmt.Errorf("unknown version, got: %T", source)
+	}
+}
+
+// deleteAnnotations deletes the annotations, leaving none rather than an empty
+// map so that the metadata round-trips.
+func deleteAnnotations(meta *metav1.ObjectMeta, keys ...string) {
+	for _, k := range keys {
+		delete(meta.Annotations, k)
+	}
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+}
+
+// convertUp returns the v1alpha2 spec and whether it matches the same events.
+func (ps *EventPolicySpec) convertUp(ctx context.Context) (v1alpha2.EventPolicySpec, bool) {
+	spec := v1alpha2.EventPolicySpec{}
+	lossless := !ps.CheckPayload
+	for _, group := range ps.Rules {
+		rule, ok := convertRuleGroupUp(ctx, group)
+		if !ok {
+			lossless = false
+			continue
+		}
+		spec.Rules = append(spec.Rules, rule)
+	}
+	return spec, lossless
+}
+
+// convertRuleGroupUp converts a group of rules into an ALLOW rule, unless
+// v1alpha2 can't match one of them.
+func convertRuleGroupUp(ctx context.Context, group []EventPolicyRule) (v1alpha2.EventRule, bool) {
+	rule := v1alpha2.EventRule{Action: v1alpha2.RuleActionAllow}
+	for _, r := range group {
+		if _, ok := r.DataPath(); ok || r.ContainsMatch != "" {
+			return rule, false
+		}
+		for _, v := range []string{r.ExactMatch, r.PrefixMatch, r.SuffixMatch} {
+			if strings.Contains(v, "*") {
+				return rule, false
+			}
+		}
+		if r.ExactMatch != "" {
+			rule.Attributes = append(rule.Attributes, v1alpha2.EventAttributeMatch{Name: r.Name, Values: []string{r.ExactMatch}})
+		}
+		if r.PrefixMatch != "" {
+			rule.Attributes = append(rule.Attributes, v1alpha2.EventAttributeMatch{Name: r.Name, Values: []string{r.PrefixMatch + "*"}})
+		}
+		if r.SuffixMatch != "" {
+			rule.Attributes = append(rule.Attributes, v1alpha2.EventAttributeMatch{Name: r.Name, Values: []string{"*" + r.SuffixMatch}})
+		}
+	}
+	// v1alpha1 doesn't validate the attribute names.
+	return rule, rule.Validate(ctx) == nil
+}
+
+// convertDown expands the values of each ALLOW rule into groups, one for each
+// combination of values. v1alpha1 only allows events, so AUDIT rules are left
+// out and DENY rules drop all the groups. ALLOW rules v1alpha1 can't express
+// are left out too.
+func (ps *EventPolicySpec) convertDown(spec *v1alpha2.EventPolicySpec) {
+	ps.Rules = nil
+	for _, r := range spec.Rules {
+		switch r.Action {
+		case "", v1alpha2.RuleActionAllow:
+		case v1alpha2.RuleActionDeny:
+			ps.Rules = nil
+			return
+		default:
+			continue
+		}
+		if groups, ok := convertRuleDown(r); ok {
+			ps.Rules = append(ps.Rules, groups...)
+		}
+	}
+}
+
+// convertRuleDown expands the values of the ALLOW rule into groups, unless
+// v1alpha1 can't match one of them.
+func convertRuleDown(r v1alpha2.EventRule) ([][]EventPolicyRule, bool) {
+	groups := [][]EventPolicyRule{{}}
+	for _, a := range r.Attributes {
+		if len(a.NotValues) > 0 {
+			return nil, false
+		}
+		var next [][]EventPolicyRule
+		for _, v := range a.Values {
+			rule, ok := convertValueDown(a.Name, v)
+			if !ok {
+				return nil, false
+			}
+			for _, g := range groups {
+				next = append(next, append(g[:len(g):len(g)], rule))
+			}
+		}
+		if len(a.Values) > 0 {
+			groups = next
+		}
+	}
+	return groups, true
+}
+
+func convertValueDown(name, v string) (EventPolicyRule, bool) {
+	switch {
+	case v == "*":
+		// v1alpha1 can't match any value.
+		return EventPolicyRule{}, false
+	case strings.HasSuffix(v, "*"):
+		return EventPolicyRule{Name: name, PrefixMatch: strings.TrimSuffix(v, "*")}, true
+	case strings.HasPrefix(v, "*"):
+		return EventPolicyRule{Name: name, SuffixMatch: strings.TrimPrefix(v, "*")}, true
+	default:
+		return EventPolicyRule{Name: name, ExactMatch: v}, true
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+)
+
+func TestEventPolicyConvertUpRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     EventPolicySpec
+		wantUp   v1alpha2.EventPolicySpec
+		wantKept bool
+	}{{
+		name: "lossless",
+		spec: EventPolicySpec{Rules: [][]EventPolicyRule{{
+			{Name: "type", ExactMatch: "dev.knative.foo"},
+			{Name: "source", PrefixMatch: "https://example.com/"},
+		}}},
+		wantUp: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Action: v1alpha2.RuleActionAllow,
+			Attributes: []v1alpha2.EventAttributeMatch{
+				{Name: "type", Values: []string{"dev.knative.foo"}},
+				{Name: "source", Values: []string{"https://example.com/*"}},
+			},
+		}}},
+	}, {
+		name: "data rules are dropped",
+		spec: EventPolicySpec{Rules: [][]EventPolicyRule{
+			{{Name: "type", SuffixMatch: ".foo"}},
+			{{Name: "data.bucket", ExactMatch: "images"}},
+		}},
+		wantUp: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Action:     v1alpha2.RuleActionAllow,
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", Values: []string{"*.foo"}}},
+		}}},
+		wantKept: true,
+	}, {
+		name: "contains rules are dropped",
+		spec: EventPolicySpec{Rules: [][]EventPolicyRule{
+			{{Name: "type", ContainsMatch: "foo"}},
+		}},
+		wantUp:   v1alpha2.EventPolicySpec{},
+		wantKept: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			in := &EventPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "policy"},
+				Spec:       tc.spec,
+			}
+			up := &v1alpha2.EventPolicy{}
+			if err := in.ConvertUp(ctx, up); err != nil {
+				t.Fatalf("ConvertUp() = %v", err)
+			}
+			if diff := cmp.Diff(tc.wantUp, up.Spec); diff != "" {
+				t.Errorf("ConvertUp() (-want, +got): %s", diff)
+			}
+			if _, kept := up.Annotations[EventPolicySpecAnnotationKey]; kept != tc.wantKept {
+				t.Errorf("ConvertUp() kept the v1alpha1 spec: %v, want %v", kept, tc.wantKept)
+			}
+
+			down := &EventPolicy{}
+			if err := down.ConvertDown(ctx, up); err != nil {
+				t.Fatalf("ConvertDown() = %v", err)
+			}
+			if diff := cmp.Diff(in, down); diff != "" {
+				t.Errorf("ConvertDown(ConvertUp()) (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestEventPolicyConvertDownRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     v1alpha2.EventPolicySpec
+		wantDown EventPolicySpec
+		wantKept bool
+	}{{
+		name: "lossless",
+		spec: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Action:     v1alpha2.RuleActionAllow,
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", Values: []string{"dev.knative.*"}}},
+		}}},
+		wantDown: EventPolicySpec{Rules: [][]EventPolicyRule{
+			{{Name: "type", PrefixMatch: "dev.knative."}},
+		}},
+	}, {
+		name: "values are expanded",
+		spec: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Attributes: []v1alpha2.EventAttributeMatch{
+				{Name: "type", Values: []string{"foo", "bar"}},
+				{Name: "source", Values: []string{"*.example.com"}},
+			},
+		}}},
+		wantDown: EventPolicySpec{Rules: [][]EventPolicyRule{
+			{{Name: "type", ExactMatch: "foo"}, {Name: "source", SuffixMatch: ".example.com"}},
+			{{Name: "type", ExactMatch: "bar"}, {Name: "source", SuffixMatch: ".example.com"}},
+		}},
+		wantKept: true,
+	}, {
+		name: "deny drops all the groups",
+		spec: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", Values: []string{"foo"}}},
+		}, {
+			Action:     v1alpha2.RuleActionDeny,
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "source", Values: []string{"bad"}}},
+		}}},
+		wantDown: EventPolicySpec{},
+		wantKept: true,
+	}, {
+		name: "audit and not values are dropped",
+		spec: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", NotValues: []string{"foo"}}},
+		}, {
+			Action:     v1alpha2.RuleActionAudit,
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", Values: []string{"bar"}}},
+		}, {
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", Values: []string{"baz"}}},
+		}}},
+		wantDown: EventPolicySpec{Rules: [][]EventPolicyRule{
+			{{Name: "type", ExactMatch: "baz"}},
+		}},
+		wantKept: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			in := &v1alpha2.EventPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "policy"},
+				Spec:       tc.spec,
+			}
+			down := &EventPolicy{}
+			if err := down.ConvertDown(ctx, in); err != nil {
+				t.Fatalf("ConvertDown() = %v", err)
+			}
+			if diff := cmp.Diff(tc.wantDown, down.Spec); diff != "" {
+				t.Errorf("ConvertDown() (-want, +got): %s", diff)
+			}
+			if _, kept := down.Annotations[EventPolicyV1alpha2SpecAnnotationKey]; kept != tc.wantKept {
+				t.Errorf("ConvertDown() kept the v1alpha2 spec: %v, want %v", kept, tc.wantKept)
+			}
+
+			up := &v1alpha2.EventPolicy{}
+			if err := down.ConvertUp(ctx, up); err != nil {
+				t.Fatalf("ConvertUp() = %v", err)
+			}
+			if diff := cmp.Diff(in, up); diff != "" {
+				t.Errorf("ConvertUp(ConvertDown()) (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestEventPolicyConvertUpChangedView(t *testing.T) {
+	ctx := context.Background()
+	in := &v1alpha2.EventPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "policy"},
+		Spec: v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+			Action:     v1alpha2.RuleActionDeny,
+			Attributes: []v1alpha2.EventAttributeMatch{{Name: "source", Values: []string{"bad"}}},
+		}}},
+	}
+	down := &EventPolicy{}
+	if err := down.ConvertDown(ctx, in); err != nil {
+		t.Fatalf("ConvertDown() = %v", err)
+	}
+
+	// Writing back a changed lossy view would drop the DENY rule.
+	down.Spec.Rules = [][]EventPolicyRule{{{Name: "type", ExactMatch: "foo"}}}
+	if err := down.ConvertUp(ctx, &v1alpha2.EventPolicy{}); err == nil {
+		t.Error("ConvertUp() = nil, want an error")
+	}
+
+	// Without the kept spec, the v1alpha1 spec replaces it.
+	delete(down.Annotations, EventPolicyV1alpha2SpecAnnotationKey)
+	up := &v1alpha2.EventPolicy{}
+	if err := down.ConvertUp(ctx, up); err != nil {
+		t.Fatalf("ConvertUp() = %v", err)
+	}
+	want := v1alpha2.EventPolicySpec{Rules: []v1alpha2.EventRule{{
+		Action:     v1alpha2.RuleActionAllow,
+		Attributes: []v1alpha2.EventAttributeMatch{{Name: "type", Values: []string{"foo"}}},
+	}}}
+	if diff := cmp.Diff(want, up.Spec); diff != "" {
+		t.Errorf("ConvertUp() (-want, +got): %s", diff)
+	}
+}
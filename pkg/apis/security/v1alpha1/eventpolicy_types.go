@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
 	policyduck "github.com/yolocs/knative-policy-binding/pkg/apis/duck/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,6 +47,7 @@ type EventPolicy struct {
 
 var (
 	_ apis.Validatable   = (*EventPolicy)(nil)
+	_ apis.Convertible   = (*EventPolicy)(nil)
 	_ apis.Defaultable   = (*EventPolicy)(nil)
 	_ apis.HasSpec       = (*EventPolicy)(nil)
 	_ runtime.Object     = (*EventPolicy)(nil)
@@ -62,6 +65,9 @@ type EventPolicySpec struct {
 
 // EventPolicyRule is a single event policy rule.
 type EventPolicyRule struct {
+	// Name is the attribute to match, e.g. "type", an extension prefixed with
+	// "ext:", or a field of the JSON data prefixed with "data.", e.g.
+	// "data.bucket". Matching the data requires CheckPayload.
 	Name          string `json:"name,omitempty"`
 	ExactMatch    string `json:"exactMatch,omitempty"`
 	PrefixMatch   string `json:"prefixMatch,omitempty"`
@@ -102,3 +108,16 @@ func (p *EventPolicy) GetGroupVersionKind() schema.GroupVersionKind {
 func (p *EventPolicy) GetUntypedSpec() interface{} {
 	return p.Spec
 }
+
+// EventPolicyDataPrefix prefixes the names of the rules matching a field of
+// the event data.
+const EventPolicyDataPrefix = "data."
+
+// DataPath returns the path to the field of the event data the rule matches,
+// if it matches one.
+func (r *EventPolicyRule) DataPath() ([]string, bool) {
+	if !strings.HasPrefix(r.Name, EventPolicyDataPrefix) {
+		return nil, false
+	}
+	return strings.Split(strings.TrimPrefix(r.Name, EventPolicyDataPrefix), "."), true
+}
@@ -23,5 +23,27 @@ import (
 )
 
 func (p *EventPolicy) Validate(ctx context.Context) *apis.FieldError {
-	return nil
+	return p.Spec.Validate(ctx).ViaField("spec")
+}
+
+func (ps *EventPolicySpec) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, group := range ps.Rules {
+		for j, r := range group {
+			path, ok := r.DataPath()
+			if !ok {
+				continue
+			}
+			if !ps.CheckPayload {
+				errs = errs.Also(apis.ErrGeneric("matching the event data requires checkPayload", "name").ViaIndex(j).ViaFieldIndex("rules", i))
+			}
+			for _, p := range path {
+				if p == "" {
+					errs = errs.Also(apis.ErrInvalidValue(r.Name, "name").ViaIndex(j).ViaFieldIndex("rules", i))
+					break
+				}
+			}
+		}
+	}
+	return errs
 }
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterHTTPPolicy is a cluster-scoped HTTPPolicy that bindings in any
+// namespace can reference.
+type ClusterHTTPPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HTTPPolicySpec `json:"spec"`
+}
+
+var (
+	_ apis.Validatable   = (*ClusterHTTPPolicy)(nil)
+	_ apis.Defaultable   = (*ClusterHTTPPolicy)(nil)
+	_ apis.HasSpec       = (*ClusterHTTPPolicy)(nil)
+	_ runtime.Object     = (*ClusterHTTPPolicy)(nil)
+	_ kmeta.OwnerRefable = (*ClusterHTTPPolicy)(nil)
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterHTTPPolicyList is a collection of ClusterHTTPPolicies.
+type ClusterHTTPPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterHTTPPolicy `json:"items"`
+}
+
+// GetGroupVersionKind returns GroupVersionKind for ClusterHTTPPolicy.
+func (p *ClusterHTTPPolicy) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("ClusterHTTPPolicy")
+}
+
+// GetUntypedSpec returns the spec of the ClusterHTTPPolicy.
+func (p *ClusterHTTPPolicy) GetUntypedSpec() interface{} {
+	return p.Spec
+}
+
+// Validate implements apis.Validatable
+func (p *ClusterHTTPPolicy) Validate(ctx context.Context) *apis.FieldError {
+	errs := p.Spec.Validate(ctx).ViaField("spec")
+	if l := getBindingLister(ctx); l != nil {
+		errs = errs.Also(p.Spec.validateBindings(l, "ClusterHTTPPolicy", "", p.Name).ViaField("spec"))
+	}
+	return errs
+}
+
+// SetDefaults implements apis.Defaultable
+func (p *ClusterHTTPPolicy) SetDefaults(ctx context.Context) {
+	p.Spec.SetDefaults(ctx)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EventPolicy is a policy for CloudEvents. Bindings enforce it as an
+// HTTPPolicy matching the CloudEvents headers, so only events in binary mode
+// can match. Policies with DENY rules deny all the structured and batched
+// events.
+type EventPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec EventPolicySpec `json:"spec"`
+}
+
+var (
+	_ apis.Validatable   = (*EventPolicy)(nil)
+	_ apis.Convertible   = (*EventPolicy)(nil)
+	_ apis.Defaultable   = (*EventPolicy)(nil)
+	_ apis.HasSpec       = (*EventPolicy)(nil)
+	_ runtime.Object     = (*EventPolicy)(nil)
+	_ kmeta.OwnerRefable = (*EventPolicy)(nil)
+)
+
+type EventPolicySpec struct {
+	// Rules to match events, with the same semantics as the HTTPPolicy rules.
+	// A policy without rules denies all events.
+	Rules []EventRule `json:"rules,omitempty"`
+}
+
+// EventRule matches the events whose attributes all match.
+type EventRule struct {
+	// Action defaults to ALLOW.
+	Action     RuleAction            `json:"action,omitempty"`
+	Attributes []EventAttributeMatch `json:"attributes,omitempty"`
+}
+
+// EventAttributeMatch matches if the attribute has any of the values and none
+// of the not values.
+type EventAttributeMatch struct {
+	// Name of the attribute, e.g. "type", or of an extension prefixed with
+	// "ext:".
+	Name      string   `json:"name,omitempty"`
+	Values    []string `json:"values,omitempty"`
+	NotValues []string `json:"notValues,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EventPolicyList is a collection of EventPolicies.
+type EventPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EventPolicy `json:"items"`
+}
+
+// GetGroupVersionKind returns GroupVersionKind for EventPolicy.
+func (p *EventPolicy) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("EventPolicy")
+}
+
+// GetUntypedSpec returns the spec of the EventPolicy.
+func (p *EventPolicy) GetUntypedSpec() interface{} {
+	return p.Spec
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+)
+
+// EventExtensionPrefix prefixes the names of the extension attributes in the
+// event rules.
+const EventExtensionPrefix = "ext:"
+
+// eventAttributes are the context attributes the event rules can match.
+var eventAttributes = sets.NewString("id", "type", "source", "subject", "dataschema", "contenttype", "encoding")
+
+// extensionName is the format of the extension names since CloudEvents v1.0.
+var extensionName = regexp.MustCompile(`^[a-z0-9]+$`)
+
+func (p *EventPolicy) Validate(ctx context.Context) *apis.FieldError {
+	return p.Spec.Validate(ctx).ViaField("spec")
+}
+
+func (ps *EventPolicySpec) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, r := range ps.Rules {
+		errs = errs.Also(r.Validate(ctx).ViaFieldIndex("rules", i))
+	}
+	return errs
+}
+
+func (r *EventRule) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	switch r.Action {
+	case "", RuleActionAllow, RuleActionDeny, RuleActionAudit:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(r.Action, "action"))
+	}
+	for i, a := range r.Attributes {
+		errs = errs.Also(a.Validate(ctx).ViaFieldIndex("attributes", i))
+	}
+	return errs
+}
+
+func (a *EventAttributeMatch) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	if strings.HasPrefix(a.Name, EventExtensionPrefix) {
+		if !extensionName.MatchString(strings.TrimPrefix(a.Name, EventExtensionPrefix)) {
+			errs = errs.Also(apis.ErrInvalidValue(a.Name, "name"))
+		}
+	} else if !eventAttributes.Has(strings.ToLower(a.Name)) {
+		errs = errs.Also(apis.ErrInvalidValue(a.Name, "name"))
+	}
+	for i, v := range a.Values {
+		if !isValidGlob(v) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(v, "values", i))
+		}
+	}
+	for i, v := range a.NotValues {
+		if !isValidGlob(v) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(v, "notValues", i))
+		}
+	}
+	return errs
+}
@@ -19,5 +19,13 @@ package v1alpha2
 import "context"
 
 func (p *HTTPPolicy) SetDefaults(ctx context.Context) {
-	// None.
+	p.Spec.SetDefaults(ctx)
+}
+
+func (ps *HTTPPolicySpec) SetDefaults(ctx context.Context) {
+	for i := range ps.Rules {
+		if ps.Rules[i].Action == "" {
+			ps.Rules[i].Action = RuleActionAllow
+		}
+	}
 }
@@ -45,32 +45,89 @@ var (
 )
 
 type HTTPPolicySpec struct {
-	JWT   JWTSpec    `json:"jwt,omitempty"`
+	JWT JWTSpec `json:"jwt,omitempty"`
+	// Rules to match requests. A request is denied if any DENY rule matches.
+	// Otherwise it's allowed if any ALLOW rule matches, or if there are only
+	// DENY or AUDIT rules. A policy without rules denies all requests.
 	Rules []RuleSpec `json:"rules,omitempty"`
+	// Rego rules merged into the policy by the opa binding class, e.g.
+	// `matched["rule"] { input.httpRequest.method == "GET" }`. They can add
+	// to the "matched", "denied" and "audited" sets of ALLOW, DENY and AUDIT
+	// rules, or define "allowed" and "denied_any" directly. Rules named
+	// "allow", like in OpenPolicies, define "allowed". A policy with Rego
+	// can't be composed with other policies, and the istio binding class
+	// doesn't support it.
+	// +optional
+	Rego string `json:"rego,omitempty"`
 }
 
 type JWTSpec struct {
-	JwksURI      string        `json:"jwksUri,omitempty"`
-	Jwks         string        `json:"jwks,omitempty"`
-	JwtHeader    string        `json:"jwtHead,omitempty"`
-	TriggerRules []TriggerRule `json:"triggerRules,omitempty"`
+	// Issuer of the JWT, required to verify JWT. JWT from other issuers are
+	// rejected.
+	Issuer  string `json:"issuer,omitempty"`
+	JwksURI string `json:"jwksUri,omitempty"`
+	Jwks    string `json:"jwks,omitempty"`
+	// JwtHeader is the header carrying the JWT. Defaults to the
+	// "Authorization" header with the "Bearer " prefix.
+	JwtHeader string `json:"jwtHead,omitempty"`
+	// JwtHeaderPrefix is stripped from the value of JwtHeader to get the JWT.
+	JwtHeaderPrefix string        `json:"jwtHeadPrefix,omitempty"`
+	TriggerRules    []TriggerRule `json:"triggerRules,omitempty"`
 }
 
+// RuleAction is the action to take when a rule matches.
+type RuleAction string
+
+const (
+	RuleActionAllow RuleAction = "ALLOW"
+	RuleActionDeny  RuleAction = "DENY"
+	// RuleActionAudit rules don't affect the decision, the matching requests
+	// are only recorded.
+	RuleActionAudit RuleAction = "AUDIT"
+)
+
 type RuleSpec struct {
+	// Action defaults to ALLOW.
+	Action RuleAction `json:"action,omitempty"`
+	// From matches the request source. A request matches if it's from any
+	// of the sources.
+	From       []Source        `json:"from,omitempty"`
 	Auth       RequestAuth     `json:"auth,omitempty"`
 	Headers    []KeyValueMatch `json:"headers,omitempty"`
 	Operations []Operation     `json:"operations,omitempty"`
 }
 
+// Operation matches requests by their host, path and method. Each "not" field
+// excludes the matching requests.
 type Operation struct {
-	Hosts   []string `json:"hosts,omitempty"`
-	Paths   []string `json:"paths,omitempty"`
-	Methods []string `json:"methods,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+	NotHosts   []string `json:"notHosts,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+	NotPaths   []string `json:"notPaths,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+	NotMethods []string `json:"notMethods,omitempty"`
 }
 
+// KeyValueMatch matches if the key has any of the values and none of the
+// not values.
 type KeyValueMatch struct {
-	Key    string   `json:"key,omitempty"`
-	Values []string `json:"values,omitempty"`
+	Key       string   `json:"key,omitempty"`
+	Values    []string `json:"values,omitempty"`
+	NotValues []string `json:"notValues,omitempty"`
+}
+
+// Source matches the peer of a request. All the fields must match.
+type Source struct {
+	// IPBlocks are the IPs or CIDR blocks of the peer.
+	IPBlocks []string `json:"ipBlocks,omitempty"`
+	// Namespaces of the peer workload, from its mTLS identity.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Principals are the mTLS identities of the peer workload, in the form
+	// of "<trust domain>/ns/<namespace>/sa/<service account>".
+	// The opa binding class only knows the identity of the requests checked
+	// through Envoy's ext_authz, so its bindings only accept namespaces and
+	// principals with the ext-authz enforcement mode.
+	Principals []string `json:"principals,omitempty"`
 }
 
 type RequestAuth struct {
@@ -18,11 +18,237 @@ package v1alpha2
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/apis"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+	"github.com/yolocs/knative-policy-binding/pkg/opa"
 )
 
+var validMethods = sets.NewString("GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE")
+
 func (p *HTTPPolicy) Validate(ctx context.Context) *apis.FieldError {
-	// To add.
+	errs := p.Spec.Validate(ctx).ViaField("spec")
+	if l := getBindingLister(ctx); l != nil {
+		errs = errs.Also(p.Spec.validateBindings(l, "HTTPPolicy", p.Namespace, p.Name).ViaField("spec"))
+	}
+	return errs
+}
+
+func (ps *HTTPPolicySpec) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	errs = errs.Also(ps.JWT.Validate(ctx).ViaField("jwt"))
+	for i, r := range ps.Rules {
+		errs = errs.Also(r.Validate(ctx).ViaFieldIndex("rules", i))
+	}
+	if ps.Rego != "" {
+		if err := opa.CheckRego(ps.Rego); err != nil {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Rego rule parsing failure: %v", err), "rego"))
+		}
+	}
+	return errs
+}
+
+// validateBindings refuses rules on source namespaces or principals if a
+// binding of the policy doesn't see the peer identity.
+func (ps *HTTPPolicySpec) validateBindings(l BindingLister, kind, namespace, name string) *apis.FieldError {
+	if !ps.usesPeerIdentity() {
+		return nil
+	}
+	bindings, err := l.ListBindings(namespace)
+	if err != nil {
+		return nil
+	}
+	for _, b := range bindings {
+		if b.DeletionTimestamp == nil && !b.seesPeerIdentity() && b.refersTo(kind, namespace, name) {
+			return apis.ErrGeneric(fmt.Sprintf("Source namespaces and principals can't be matched, HTTPPolicyBinding %s/%s only sees them with the %s enforcement mode",
+				b.Namespace, b.Name, security.EnforcementModeExtAuthz), "rules")
+		}
+	}
+	return nil
+}
+
+// usesPeerIdentity tells if the rules match source namespaces or principals.
+func (ps *HTTPPolicySpec) usesPeerIdentity() bool {
+	for _, r := range ps.Rules {
+		for _, src := range r.From {
+			if len(src.Namespaces) > 0 || len(src.Principals) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (js *JWTSpec) Validate(ctx context.Context) *apis.FieldError {
+	if js.Issuer == "" && js.JwksURI == "" && js.Jwks == "" && js.JwtHeader == "" && js.JwtHeaderPrefix == "" && len(js.TriggerRules) == 0 {
+		// JWT is not used.
+		return nil
+	}
+
+	var errs *apis.FieldError
+	if js.Issuer == "" {
+		errs = errs.Also(apis.ErrMissingField("issuer"))
+	}
+	if js.JwtHeaderPrefix != "" && js.JwtHeader == "" {
+		errs = errs.Also(apis.ErrMissingField("jwtHead"))
+	}
+	switch {
+	case js.JwksURI == "" && js.Jwks == "":
+		errs = errs.Also(apis.ErrMissingOneOf("jwksUri", "jwks"))
+	case js.JwksURI != "" && js.Jwks != "":
+		errs = errs.Also(apis.ErrMultipleOneOf("jwksUri", "jwks"))
+	case js.JwksURI != "":
+		if u, err := url.Parse(js.JwksURI); err != nil || !u.IsAbs() || u.Host == "" {
+			errs = errs.Also(apis.ErrInvalidValue(js.JwksURI, "jwksUri"))
+		}
+	default:
+		if err := validateJwks(js.Jwks); err != nil {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Invalid JWKS: %v", err), "jwks"))
+		}
+	}
+
+	for i, tr := range js.TriggerRules {
+		errs = errs.Also(tr.Validate(ctx).ViaFieldIndex("triggerRules", i))
+	}
+	return errs
+}
+
+func (tr *TriggerRule) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, p := range tr.IncludePaths {
+		errs = errs.Also(validatePath(p, "includePaths", i))
+	}
+	for i, p := range tr.ExcludePaths {
+		errs = errs.Also(validatePath(p, "excludePaths", i))
+	}
+	return errs
+}
+
+func (rs *RuleSpec) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	switch rs.Action {
+	case "", RuleActionAllow, RuleActionDeny, RuleActionAudit:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(rs.Action, "action"))
+	}
+	for i, src := range rs.From {
+		errs = errs.Also(src.Validate(ctx).ViaFieldIndex("from", i))
+	}
+	for i, h := range rs.Headers {
+		errs = errs.Also(h.Validate(ctx).ViaFieldIndex("headers", i))
+	}
+	for i, op := range rs.Operations {
+		errs = errs.Also(op.Validate(ctx).ViaFieldIndex("operations", i))
+	}
+	for i, c := range rs.Auth.Claims {
+		errs = errs.Also(c.Validate(ctx).ViaFieldIndex("claims", i).ViaField("auth"))
+	}
+	return errs
+}
+
+func (src *Source) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, b := range src.IPBlocks {
+		if _, _, err := net.ParseCIDR(b); err != nil && net.ParseIP(b) == nil {
+			errs = errs.Also(apis.ErrInvalidArrayValue(b, "ipBlocks", i))
+		}
+	}
+	for i, ns := range src.Namespaces {
+		if !isValidGlob(ns) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(ns, "namespaces", i))
+		}
+	}
+	for i, p := range src.Principals {
+		if !isValidGlob(p) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(p, "principals", i))
+		}
+	}
+	return errs
+}
+
+func (op *Operation) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	errs = errs.Also(validateMethods(op.Methods, "methods"), validateMethods(op.NotMethods, "notMethods"))
+	errs = errs.Also(validateHosts(op.Hosts, "hosts"), validateHosts(op.NotHosts, "notHosts"))
+	for i, p := range op.Paths {
+		errs = errs.Also(validatePath(p, "paths", i))
+	}
+	for i, p := range op.NotPaths {
+		errs = errs.Also(validatePath(p, "notPaths", i))
+	}
+	return errs
+}
+
+func (kv *KeyValueMatch) Validate(ctx context.Context) *apis.FieldError {
+	if strings.TrimSpace(kv.Key) == "" {
+		return apis.ErrMissingField("key")
+	}
+	return nil
+}
+
+func validateMethods(methods []string, field string) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, m := range methods {
+		if !validMethods.Has(m) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(m, field, i))
+		}
+	}
+	return errs
+}
+
+func validateHosts(hosts []string, field string) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, h := range hosts {
+		if !isValidGlob(h) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(h, field, i))
+		}
+	}
+	return errs
+}
+
+// isValidGlob checks the value is either exact or has a single "*" at the
+// beginning or the end, which is all the policy engines support.
+func isValidGlob(v string) bool {
+	if v == "" {
+		return false
+	}
+	if v == "*" {
+		return true
+	}
+	return !strings.Contains(strings.TrimPrefix(v, "*"), "*") || !strings.Contains(strings.TrimSuffix(v, "*"), "*")
+}
+
+// validatePath checks a path glob. Unless it only matches the suffix, a path
+// must be absolute.
+func validatePath(p, field string, index int) *apis.FieldError {
+	if !isValidGlob(p) || (!strings.HasPrefix(p, "*") && !strings.HasPrefix(p, "/")) {
+		return apis.ErrInvalidArrayValue(p, field, index)
+	}
+	return nil
+}
+
+// validateJwks checks the value is a JSON Web Key Set with at least one key.
+func validateJwks(jwks string) error {
+	var set struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(jwks), &set); err != nil {
+		return err
+	}
+	if len(set.Keys) == 0 {
+		return fmt.Errorf("no keys found")
+	}
+	for i, k := range set.Keys {
+		if kty, _ := k["kty"].(string); kty == "" {
+			return fmt.Errorf("keys[%d] has no kty", i)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,51 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// BindingLister looks up HTTPPolicyBindings and the policies they refer to, so
+// that bindings and policies can be validated against each other at
+// admission.
+type BindingLister interface {
+	// ListBindings lists the HTTPPolicyBindings of the namespace, or of all
+	// the namespaces if it's empty.
+	ListBindings(namespace string) ([]*HTTPPolicyBinding, error)
+	// GetPolicySpec gets the spec of the HTTPPolicy or ClusterHTTPPolicy the
+	// reference points to. It returns nil if there is no such policy.
+	GetPolicySpec(ref corev1.ObjectReference) (*HTTPPolicySpec, error)
+}
+
+type bindingListerKey struct{}
+
+// WithBindingLister makes the validation of bindings and policies check them
+// against each other with the lister.
+func WithBindingLister(ctx context.Context, l BindingLister) context.Context {
+	return context.WithValue(ctx, bindingListerKey{}, l)
+}
+
+func getBindingLister(ctx context.Context) BindingLister {
+	value := ctx.Value(bindingListerKey{})
+	if value == nil {
+		return nil
+	}
+	return value.(BindingLister)
+}
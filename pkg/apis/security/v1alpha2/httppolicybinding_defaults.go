@@ -16,14 +16,42 @@ limitations under the License.
 
 package v1alpha2
 
-import "context"
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/config"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+)
 
 // SetDefaults implements apis.Defaultable
 func (pb *HTTPPolicyBinding) SetDefaults(ctx context.Context) {
-	if pb.Spec.Subject.Namespace == "" {
+	if pb.GetAnnotations()[security.BindingClassAnnotationKey] == "" {
+		if class := config.FromContextOrDefaults(ctx).Binding.DefaultBindingClass(pb.Namespace); class != "" {
+			if pb.Annotations == nil {
+				pb.Annotations = map[string]string{}
+			}
+			pb.Annotations[security.BindingClassAnnotationKey] = class
+		}
+	}
+	if pb.Spec.Subject != nil && pb.Spec.Subject.Namespace == "" {
 		pb.Spec.Subject.Namespace = pb.Namespace
 	}
-	if pb.Spec.Policy.Namespace == "" {
-		pb.Spec.Policy.Namespace = pb.Namespace
+	if pb.Spec.Policy != nil {
+		pb.defaultPolicyRef(pb.Spec.Policy)
+	}
+	for i := range pb.Spec.Policies {
+		pb.defaultPolicyRef(&pb.Spec.Policies[i])
+	}
+	if len(pb.Spec.Policies) > 0 && pb.Spec.Composition == "" {
+		pb.Spec.Composition = PolicyCompositionAllOf
+	}
+}
+
+// defaultPolicyRef puts HTTPPolicy references in the binding namespace.
+func (pb *HTTPPolicyBinding) defaultPolicyRef(ref *corev1.ObjectReference) {
+	if ref.Kind != "ClusterHTTPPolicy" && ref.Namespace == "" {
+		ref.Namespace = pb.Namespace
 	}
 }
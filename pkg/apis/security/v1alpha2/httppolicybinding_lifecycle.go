@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/apis/duck"
+	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/tracker"
 )
 
@@ -31,6 +32,28 @@ var httpPolicyBindingCondSet = apis.NewLivingConditionSet(
 const (
 	HTTPPolicyBindingConditionReady                          = apis.ConditionReady
 	HTTPPolicyAuthorizableSubjectResolved apis.ConditionType = "AuthorizableSubjectResolved"
+
+	// HTTPPolicyBindingConditionAgentsConverged is only reported for bindings
+	// whose policy is updated in place. It doesn't affect the Ready condition.
+	HTTPPolicyBindingConditionAgentsConverged apis.ConditionType = "AgentsConverged"
+
+	// HTTPPolicyBindingConditionClassClaimed tells if a controller of the
+	// binding class enforces the binding.
+	HTTPPolicyBindingConditionClassClaimed apis.ConditionType = "BindingClassClaimed"
+
+	// HTTPPolicyBindingConditionClassHandover is only reported for bindings
+	// whose class changed. It tells if the controllers of the previous class
+	// removed what they created for the binding.
+	HTTPPolicyBindingConditionClassHandover apis.ConditionType = "BindingClassHandover"
+
+	// HTTPPolicyBindingConditionAuthorizationPolicyReady is only reported
+	// for bindings enforced by Istio AuthorizationPolicies. It tells if they
+	// exist and match the policy.
+	HTTPPolicyBindingConditionAuthorizationPolicyReady apis.ConditionType = "AuthorizationPolicyReady"
+
+	// HTTPPolicyBindingConditionRequestAuthenticationReady is only reported
+	// for bindings that verify JWT with an Istio RequestAuthentication.
+	HTTPPolicyBindingConditionRequestAuthenticationReady apis.ConditionType = "RequestAuthenticationReady"
 )
 
 // GetGroupVersionKind returns GroupVersionKind for Triggers
@@ -95,3 +118,107 @@ func (pbs *HTTPPolicyBindingStatus) MarkBindingSubjectResolved(sub *tracker.Refe
 func (pbs *HTTPPolicyBindingStatus) MarkBindingSubjectResolvingFaiulre(reason, messageFormat string, messageA ...interface{}) {
 	httpPolicyBindingCondSet.Manage(pbs).MarkFalse(HTTPPolicyAuthorizableSubjectResolved, reason, messageFormat, messageA...)
 }
+
+// MarkBindingClassClaimed marks a controller of the binding class enforces the
+// binding.
+func (pbs *HTTPPolicyBindingStatus) MarkBindingClassClaimed() {
+	httpPolicyBindingCondSet.Manage(pbs).MarkTrue(HTTPPolicyBindingConditionClassClaimed)
+}
+
+// MarkBindingClassUnclaimed marks no controller enforces the binding class,
+// which makes the binding not ready.
+func (pbs *HTTPPolicyBindingStatus) MarkBindingClassUnclaimed(class string) {
+	m := httpPolicyBindingCondSet.Manage(pbs)
+	if class == "" {
+		m.MarkFalse(HTTPPolicyBindingConditionClassClaimed, "NoBindingClass", "The binding has no class and there is no default class")
+	} else {
+		m.MarkFalse(HTTPPolicyBindingConditionClassClaimed, "NoController", "No controller claims binding class %q", class)
+	}
+	m.MarkFalse(HTTPPolicyBindingConditionReady, "BindingClassUnclaimed", "No controller enforces the binding")
+}
+
+// MarkClassHandoverInProgress marks the binding classes are removing what
+// they created for the binding.
+func (pbs *HTTPPolicyBindingStatus) MarkClassHandoverInProgress(classes []string) {
+	httpPolicyBindingCondSet.Manage(pbs).MarkUnknown(HTTPPolicyBindingConditionClassHandover, "CleaningUp", "Waiting for binding classes %v to remove their resources", classes)
+}
+
+// MarkClassHandoverFailed marks a binding class failed to remove what it
+// created for the binding.
+func (pbs *HTTPPolicyBindingStatus) MarkClassHandoverFailed(reason, messageFormat string, messageA ...interface{}) {
+	httpPolicyBindingCondSet.Manage(pbs).MarkFalse(HTTPPolicyBindingConditionClassHandover, reason, messageFormat, messageA...)
+}
+
+// MarkClassHandoverComplete marks only the current binding class has
+// resources for the binding.
+func (pbs *HTTPPolicyBindingStatus) MarkClassHandoverComplete() {
+	httpPolicyBindingCondSet.Manage(pbs).MarkTrue(HTTPPolicyBindingConditionClassHandover)
+}
+
+// MarkAgentsConverged marks all agents enforce the policy revision.
+func (pbs *HTTPPolicyBindingStatus) MarkAgentsConverged() {
+	httpPolicyBindingCondSet.Manage(pbs).MarkTrue(HTTPPolicyBindingConditionAgentsConverged)
+}
+
+// MarkAgentsConverging marks some agents are yet to enforce the policy revision.
+func (pbs *HTTPPolicyBindingStatus) MarkAgentsConverging(reason, messageFormat string, messageA ...interface{}) {
+	httpPolicyBindingCondSet.Manage(pbs).MarkUnknown(HTTPPolicyBindingConditionAgentsConverged, reason, messageFormat, messageA...)
+}
+
+// ClearAgentsConverged removes the AgentsConverged condition.
+func (pbs *HTTPPolicyBindingStatus) ClearAgentsConverged() {
+	httpPolicyBindingCondSet.Manage(pbs).ClearCondition(HTTPPolicyBindingConditionAgentsConverged)
+}
+
+// MarkAuthorizationPolicyReady marks the AuthorizationPolicies match the policy.
+func (pbs *HTTPPolicyBindingStatus) MarkAuthorizationPolicyReady() {
+	httpPolicyBindingCondSet.Manage(pbs).MarkTrue(HTTPPolicyBindingConditionAuthorizationPolicyReady)
+}
+
+// MarkAuthorizationPolicyFailed marks the AuthorizationPolicy failure.
+func (pbs *HTTPPolicyBindingStatus) MarkAuthorizationPolicyFailed(reason, messageFormat string, messageA ...interface{}) {
+	httpPolicyBindingCondSet.Manage(pbs).MarkFalse(HTTPPolicyBindingConditionAuthorizationPolicyReady, reason, messageFormat, messageA...)
+}
+
+// ClearAuthorizationPolicyReady removes the AuthorizationPolicyReady condition.
+func (pbs *HTTPPolicyBindingStatus) ClearAuthorizationPolicyReady() {
+	httpPolicyBindingCondSet.Manage(pbs).ClearCondition(HTTPPolicyBindingConditionAuthorizationPolicyReady)
+}
+
+// MarkRequestAuthenticationReady marks the RequestAuthentication is reconciled.
+func (pbs *HTTPPolicyBindingStatus) MarkRequestAuthenticationReady() {
+	httpPolicyBindingCondSet.Manage(pbs).MarkTrue(HTTPPolicyBindingConditionRequestAuthenticationReady)
+}
+
+// MarkRequestAuthenticationFailed marks the RequestAuthentication failure.
+func (pbs *HTTPPolicyBindingStatus) MarkRequestAuthenticationFailed(reason, messageFormat string, messageA ...interface{}) {
+	httpPolicyBindingCondSet.Manage(pbs).MarkFalse(HTTPPolicyBindingConditionRequestAuthenticationReady, reason, messageFormat, messageA...)
+}
+
+// ClearRequestAuthenticationReady removes the RequestAuthenticationReady condition.
+func (pbs *HTTPPolicyBindingStatus) ClearRequestAuthenticationReady() {
+	httpPolicyBindingCondSet.Manage(pbs).ClearCondition(HTTPPolicyBindingConditionRequestAuthenticationReady)
+}
+
+// MarkPoliciesResolved records the HTTPPolicies and ClusterHTTPPolicies
+// being enforced.
+func (pbs *HTTPPolicyBindingStatus) MarkPoliciesResolved(policies []kmeta.OwnerRefable) {
+	pbs.ResolvedPolicies = make([]corev1.ObjectReference, 0, len(policies))
+	for _, p := range policies {
+		apiVersion, kind := p.GetGroupVersionKind().ToAPIVersionAndKind()
+		meta := p.GetObjectMeta()
+		pbs.ResolvedPolicies = append(pbs.ResolvedPolicies, corev1.ObjectReference{
+			APIVersion:      apiVersion,
+			Kind:            kind,
+			Namespace:       meta.GetNamespace(),
+			Name:            meta.GetName(),
+			UID:             meta.GetUID(),
+			ResourceVersion: meta.GetResourceVersion(),
+		})
+	}
+}
+
+// SetGeneratedObjects records the objects created to enforce the binding.
+func (pbs *HTTPPolicyBindingStatus) SetGeneratedObjects(objs []corev1.ObjectReference) {
+	pbs.GeneratedObjects = objs
+}
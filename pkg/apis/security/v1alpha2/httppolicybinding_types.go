@@ -48,8 +48,62 @@ var (
 )
 
 type HTTPPolicyBindingSpec struct {
-	Subject *corev1.ObjectReference `json:"subject"`
-	Policy  *corev1.ObjectReference `json:"policy"`
+	// Subject is the authorizable to bind the policy to. Exactly one of
+	// Subject and Workloads is required.
+	Subject *corev1.ObjectReference `json:"subject,omitempty"`
+	// Workloads binds the policy to all the workloads in the binding
+	// namespace instead. Requests not allowed by the policy are denied even
+	// if it only has DENY or AUDIT rules. Bindings with a Subject of the same
+	// class compose the namespace-wide bindings selecting their pods: the
+	// requests allowed by either binding are allowed, and the DENY rules of
+	// both apply.
+	Workloads *WorkloadsSubject `json:"workloads,omitempty"`
+	// Policy is the HTTPPolicy or ClusterHTTPPolicy to enforce. Exactly one
+	// of Policy and Policies is required.
+	Policy *corev1.ObjectReference `json:"policy,omitempty"`
+	// Policies are multiple policies to enforce, combined as specified by
+	// Composition.
+	Policies []corev1.ObjectReference `json:"policies,omitempty"`
+	// Composition is how the ALLOW rules of Policies combine. DENY and AUDIT
+	// rules are not scoped to their policy: a request denied by any policy
+	// is denied, whatever the composition. Defaults to AllOf.
+	Composition PolicyComposition `json:"composition,omitempty"`
+}
+
+// WorkloadsSubject selects the workloads in the binding namespace.
+type WorkloadsSubject struct {
+	// Selector filters the workloads by their labels. All the workloads are
+	// selected if it's omitted.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// IsNamespaceWide tells if the binding applies to the workloads of its
+// namespace rather than a subject.
+func (s *HTTPPolicyBindingSpec) IsNamespaceWide() bool {
+	return s.Workloads != nil
+}
+
+// PolicyComposition is how multiple policies combine.
+type PolicyComposition string
+
+const (
+	// PolicyCompositionAnyOf allows requests matching the ALLOW rules of any
+	// of the policies, unless they match a DENY rule of any of the policies.
+	// A policy with rules but no ALLOW rule allows all requests.
+	PolicyCompositionAnyOf PolicyComposition = "AnyOf"
+	// PolicyCompositionAllOf allows requests matching the ALLOW rules of all
+	// the policies, unless they match a DENY rule of any of the policies.
+	PolicyCompositionAllOf PolicyComposition = "AllOf"
+)
+
+// PolicyRefs returns the references to all the policies to enforce.
+func (s *HTTPPolicyBindingSpec) PolicyRefs() []corev1.ObjectReference {
+	var refs []corev1.ObjectReference
+	if s.Policy != nil {
+		refs = append(refs, *s.Policy)
+	}
+	return append(refs, s.Policies...)
 }
 
 type HTTPPolicyBindingStatus struct {
@@ -60,6 +114,17 @@ type HTTPPolicyBindingStatus struct {
 
 	// ResolvedSubject is resolved policy subject.
 	ResolvedSubject *tracker.Reference `json:"resolvedSubject,omitempty"`
+
+	// ResolvedPolicies are the policies being enforced, with the resource
+	// versions they were resolved at.
+	ResolvedPolicies []corev1.ObjectReference `json:"resolvedPolicies,omitempty"`
+
+	// PolicyRevision is the revision of the policy the agents are expected
+	// to enforce.
+	PolicyRevision string `json:"policyRevision,omitempty"`
+
+	// GeneratedObjects are the objects created to enforce the binding.
+	GeneratedObjects []corev1.ObjectReference `json:"generatedObjects,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
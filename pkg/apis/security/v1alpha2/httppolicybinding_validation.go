@@ -18,18 +18,211 @@ package v1alpha2
 
 import (
 	"context"
+	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/apis"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
 )
 
 // Validate implements apis.Validatable
 func (pb *HTTPPolicyBinding) Validate(ctx context.Context) *apis.FieldError {
 	var errs *apis.FieldError
-	if pb.Spec.Subject.Namespace != "" && pb.Namespace != pb.Spec.Subject.Namespace {
-		errs = errs.Also(apis.ErrInvalidValue(pb.Spec.Subject.Namespace, "spec.subject.namespace"))
+	if class, ok := pb.GetAnnotations()[security.BindingClassAnnotationKey]; ok && !security.IsBindingClassRegistered(class) {
+		errs = errs.Also(apis.ErrInvalidValue(class, "metadata.annotations["+security.BindingClassAnnotationKey+"]"))
+	}
+	errs = errs.Also(pb.validateEnforcementMode())
+	switch {
+	case pb.Spec.Subject == nil && pb.Spec.Workloads == nil:
+		errs = errs.Also(apis.ErrMissingOneOf("spec.subject", "spec.workloads"))
+	case pb.Spec.Subject != nil && pb.Spec.Workloads != nil:
+		errs = errs.Also(apis.ErrMultipleOneOf("spec.subject", "spec.workloads"))
+	case pb.Spec.Subject != nil:
+		if pb.Spec.Subject.Namespace != "" && pb.Namespace != pb.Spec.Subject.Namespace {
+			errs = errs.Also(apis.ErrInvalidValue(pb.Spec.Subject.Namespace, "spec.subject.namespace"))
+		}
+	case pb.Spec.Workloads.Selector != nil:
+		if _, err := metav1.LabelSelectorAsSelector(pb.Spec.Workloads.Selector); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), "spec.workloads.selector"))
+		}
+	}
+	switch {
+	case pb.Spec.Policy == nil && len(pb.Spec.Policies) == 0:
+		errs = errs.Also(apis.ErrMissingOneOf("spec.policy", "spec.policies"))
+	case pb.Spec.Policy != nil && len(pb.Spec.Policies) > 0:
+		errs = errs.Also(apis.ErrMultipleOneOf("spec.policy", "spec.policies"))
+	case pb.Spec.Policy != nil:
+		errs = errs.Also(pb.validatePolicyRef(pb.Spec.Policy).ViaField("spec.policy"))
+	}
+	for i := range pb.Spec.Policies {
+		errs = errs.Also(pb.validatePolicyRef(&pb.Spec.Policies[i]).ViaFieldIndex("spec.policies", i))
+	}
+	switch pb.Spec.Composition {
+	case "", PolicyCompositionAnyOf, PolicyCompositionAllOf:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(pb.Spec.Composition, "spec.composition"))
+	}
+	if l := getBindingLister(ctx); l != nil && pb.DeletionTimestamp == nil {
+		errs = errs.Also(pb.validatePeerIdentity(l))
+		errs = errs.Also(pb.validateConflicts(l))
+	}
+	return errs
+}
+
+// validateConflicts refuses opa bindings applying to the workloads another
+// opa binding already applies to. Their agents can't be injected into the
+// same pods, so only one of them would be enforced. Namespace-wide bindings
+// don't conflict with the bindings of a subject, which compose them.
+func (pb *HTTPPolicyBinding) validateConflicts(l BindingLister) *apis.FieldError {
+	if pb.GetAnnotations()[security.BindingClassAnnotationKey] != security.OPABindingClass {
+		return nil
+	}
+	bindings, err := l.ListBindings(pb.Namespace)
+	if err != nil {
+		return nil
+	}
+	field := "spec.subject"
+	if pb.Spec.IsNamespaceWide() {
+		field = "spec.workloads"
+	}
+	for _, o := range bindings {
+		if o.Name == pb.Name || o.DeletionTimestamp != nil ||
+			o.GetAnnotations()[security.BindingClassAnnotationKey] != security.OPABindingClass {
+			continue
+		}
+		if pb.overlaps(o) {
+			return apis.ErrGeneric(fmt.Sprintf("HTTPPolicyBinding %q already binds the %s class to the same workloads, "+
+				"compose the policies in one binding instead", o.Name, security.OPABindingClass), field)
+		}
+	}
+	return nil
+}
+
+// overlaps tells if the bindings may apply to the same workloads, both for a
+// subject or both namespace-wide.
+func (pb *HTTPPolicyBinding) overlaps(o *HTTPPolicyBinding) bool {
+	a, b := pb.Spec, o.Spec
+	switch {
+	case a.Subject != nil && b.Subject != nil:
+		ga, _ := schema.ParseGroupVersion(a.Subject.APIVersion)
+		gb, _ := schema.ParseGroupVersion(b.Subject.APIVersion)
+		return ga.Group == gb.Group && a.Subject.Kind == b.Subject.Kind && a.Subject.Name == b.Subject.Name
+	case a.Workloads != nil && b.Workloads != nil:
+		return !disjoint(a.Workloads.Selector, b.Workloads.Selector) && !disjoint(b.Workloads.Selector, a.Workloads.Selector)
+	}
+	return false
+}
+
+// disjoint tells if no labels can be selected by both selectors because a
+// label a requires has a value b doesn't accept.
+func disjoint(a, b *metav1.LabelSelector) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	sb, err := metav1.LabelSelectorAsSelector(b)
+	if err != nil {
+		return false
+	}
+	reqs, _ := sb.Requirements()
+	for _, r := range reqs {
+		if v, ok := a.MatchLabels[r.Key()]; ok && !r.Matches(labels.Set{r.Key(): v}) {
+			return true
+		}
+	}
+	return false
+}
+
+// seesPeerIdentity tells if the binding is enforced knowing the identity of
+// the peers. The opa class only knows it with the ext-authz enforcement mode.
+func (pb *HTTPPolicyBinding) seesPeerIdentity() bool {
+	return pb.GetAnnotations()[security.BindingClassAnnotationKey] != security.OPABindingClass ||
+		pb.GetAnnotations()[security.EnforcementModeAnnotationKey] == security.EnforcementModeExtAuthz
+}
+
+// validatePeerIdentity refuses the policies with rules on source namespaces
+// or principals if the binding doesn't see the peer identity. The rules would
+// never match, or deny all the requests.
+func (pb *HTTPPolicyBinding) validatePeerIdentity(l BindingLister) *apis.FieldError {
+	if pb.seesPeerIdentity() {
+		return nil
+	}
+	check := func(ref *corev1.ObjectReference) *apis.FieldError {
+		spec, err := l.GetPolicySpec(*ref)
+		if err != nil || spec == nil || !spec.usesPeerIdentity() {
+			return nil
+		}
+		return apis.ErrGeneric("The policy matches source namespaces or principals, which the binding only sees with the "+
+			security.EnforcementModeExtAuthz+" enforcement mode", apis.CurrentField)
+	}
+
+	var errs *apis.FieldError
+	if pb.Spec.Policy != nil {
+		errs = errs.Also(check(pb.Spec.Policy).ViaField("spec.policy"))
+	}
+	for i := range pb.Spec.Policies {
+		errs = errs.Also(check(&pb.Spec.Policies[i]).ViaFieldIndex("spec.policies", i))
+	}
+	return errs
+}
+
+// refersTo tells if the binding refers to the policy of the kind.
+func (pb *HTTPPolicyBinding) refersTo(kind, namespace, name string) bool {
+	for _, ref := range pb.Spec.PolicyRefs() {
+		refKind, refNamespace := ref.Kind, ref.Namespace
+		if refKind == "" {
+			refKind = "HTTPPolicy"
+		}
+		if refNamespace == "" && refKind != "ClusterHTTPPolicy" {
+			refNamespace = pb.Namespace
+		}
+		if refKind == kind && refNamespace == namespace && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEnforcementMode refuses to proxy Knative Services, Knative rejects
+// the PORT env the proxy relies on.
+func (pb *HTTPPolicyBinding) validateEnforcementMode() *apis.FieldError {
+	field := "metadata.annotations[" + security.EnforcementModeAnnotationKey + "]"
+	switch mode := pb.GetAnnotations()[security.EnforcementModeAnnotationKey]; mode {
+	case "", security.EnforcementModeExtAuthz:
+	case security.EnforcementModeProxy:
+		if pb.Spec.Subject == nil {
+			return nil
+		}
+		if gv, err := schema.ParseGroupVersion(pb.Spec.Subject.APIVersion); err == nil && gv.Group == "serving.knative.dev" {
+			return apis.ErrGeneric("Knative Services can't be proxied, Knative sets the PORT env itself", field)
+		}
+	default:
+		return apis.ErrInvalidValue(mode, field)
+	}
+	return nil
+}
+
+// validatePolicyRef only allows HTTPPolicies and EventPolicies in the binding
+// namespace or ClusterHTTPPolicies.
+func (pb *HTTPPolicyBinding) validatePolicyRef(ref *corev1.ObjectReference) *apis.FieldError {
+	var errs *apis.FieldError
+	if ref.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("name"))
 	}
-	if pb.Spec.Policy.Namespace != "" && pb.Namespace != pb.Spec.Policy.Namespace {
-		errs = errs.Also(apis.ErrInvalidValue(pb.Spec.Policy.Namespace, "spec.policy.namespace"))
+	switch ref.Kind {
+	case "", "HTTPPolicy", "EventPolicy":
+		if ref.Namespace != "" && pb.Namespace != ref.Namespace {
+			errs = errs.Also(apis.ErrInvalidValue(ref.Namespace, "namespace"))
+		}
+	case "ClusterHTTPPolicy":
+		if ref.Namespace != "" {
+			errs = errs.Also(apis.ErrDisallowedFields("namespace"))
+		}
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(ref.Kind, "kind"))
 	}
 	return errs
 }
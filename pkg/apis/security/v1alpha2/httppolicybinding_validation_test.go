@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+)
+
+type fakeBindingLister struct {
+	bindings []*HTTPPolicyBinding
+	policies map[string]*HTTPPolicySpec
+}
+
+func (l *fakeBindingLister) ListBindings(namespace string) ([]*HTTPPolicyBinding, error) {
+	var bindings []*HTTPPolicyBinding
+	for _, b := range l.bindings {
+		if namespace == "" || b.Namespace == namespace {
+			bindings = append(bindings, b)
+		}
+	}
+	return bindings, nil
+}
+
+func (l *fakeBindingLister) GetPolicySpec(ref corev1.ObjectReference) (*HTTPPolicySpec, error) {
+	return l.policies[ref.Name], nil
+}
+
+func binding(name string, annotations map[string]string, spec HTTPPolicyBindingSpec) *HTTPPolicyBinding {
+	if spec.Policy == nil && len(spec.Policies) == 0 {
+		spec.Policy = &corev1.ObjectReference{Kind: "HTTPPolicy", Namespace: "ns", Name: "policy"}
+	}
+	return &HTTPPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Annotations: annotations},
+		Spec:       spec,
+	}
+}
+
+func deployment(name string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: name}
+}
+
+func workloads(matchLabels map[string]string) *WorkloadsSubject {
+	if matchLabels == nil {
+		return &WorkloadsSubject{}
+	}
+	return &WorkloadsSubject{Selector: &metav1.LabelSelector{MatchLabels: matchLabels}}
+}
+
+func TestHTTPPolicyBindingValidateAgainstOthers(t *testing.T) {
+	opa := map[string]string{security.BindingClassAnnotationKey: security.OPABindingClass}
+	extAuthz := map[string]string{
+		security.BindingClassAnnotationKey:    security.OPABindingClass,
+		security.EnforcementModeAnnotationKey: security.EnforcementModeExtAuthz,
+	}
+	istio := map[string]string{security.BindingClassAnnotationKey: security.IstioBindingClass}
+	peerPolicy := &corev1.ObjectReference{Kind: "HTTPPolicy", Namespace: "ns", Name: "peer"}
+	policies := map[string]*HTTPPolicySpec{
+		"policy": {Rules: []RuleSpec{{Operations: []Operation{{Methods: []string{"GET"}}}}}},
+		"peer":   {Rules: []RuleSpec{{From: []Source{{Namespaces: []string{"other"}}}}}},
+	}
+
+	tests := []struct {
+		name     string
+		binding  *HTTPPolicyBinding
+		existing []*HTTPPolicyBinding
+		wantErr  bool
+	}{{
+		name:    "opa binding without peer policy",
+		binding: binding("b", opa, HTTPPolicyBindingSpec{Subject: deployment("app")}),
+	}, {
+		name:    "opa binding with peer policy",
+		binding: binding("b", opa, HTTPPolicyBindingSpec{Subject: deployment("app"), Policy: peerPolicy}),
+		wantErr: true,
+	}, {
+		name:    "opa ext-authz binding with peer policy",
+		binding: binding("b", extAuthz, HTTPPolicyBindingSpec{Subject: deployment("app"), Policy: peerPolicy}),
+	}, {
+		name:    "istio binding with peer policy",
+		binding: binding("b", istio, HTTPPolicyBindingSpec{Subject: deployment("app"), Policy: peerPolicy}),
+	}, {
+		name:     "same subject",
+		binding:  binding("b", opa, HTTPPolicyBindingSpec{Subject: deployment("app")}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Subject: deployment("app")})},
+		wantErr:  true,
+	}, {
+		name:     "update of itself",
+		binding:  binding("a", opa, HTTPPolicyBindingSpec{Subject: deployment("app")}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Subject: deployment("app")})},
+	}, {
+		name:     "other subject",
+		binding:  binding("b", opa, HTTPPolicyBindingSpec{Subject: deployment("app")}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Subject: deployment("other")})},
+	}, {
+		name:     "same subject of another class",
+		binding:  binding("b", opa, HTTPPolicyBindingSpec{Subject: deployment("app")}),
+		existing: []*HTTPPolicyBinding{binding("a", istio, HTTPPolicyBindingSpec{Subject: deployment("app")})},
+	}, {
+		name:     "subject and namespace-wide",
+		binding:  binding("b", opa, HTTPPolicyBindingSpec{Subject: deployment("app")}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Workloads: workloads(nil)})},
+	}, {
+		name:     "namespace-wide",
+		binding:  binding("b", opa, HTTPPolicyBindingSpec{Workloads: workloads(map[string]string{"app": "web"})}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Workloads: workloads(nil)})},
+		wantErr:  true,
+	}, {
+		name:    "namespace-wide with disjoint selectors",
+		binding: binding("b", opa, HTTPPolicyBindingSpec{Workloads: workloads(map[string]string{"app": "web"})}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Workloads: &WorkloadsSubject{
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "app",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"web"},
+			}}},
+		}})},
+	}, {
+		name:     "namespace-wide with overlapping selectors",
+		binding:  binding("b", opa, HTTPPolicyBindingSpec{Workloads: workloads(map[string]string{"app": "web"})}),
+		existing: []*HTTPPolicyBinding{binding("a", opa, HTTPPolicyBindingSpec{Workloads: workloads(map[string]string{"tier": "front"})})},
+		wantErr:  true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &fakeBindingLister{bindings: tc.existing, policies: policies}
+			err := tc.binding.Validate(WithBindingLister(context.Background(), l))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
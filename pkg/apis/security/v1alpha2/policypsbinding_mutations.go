@@ -18,11 +18,16 @@ package v1alpha2
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	jsonpatch "gomodules.xyz/jsonpatch/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"knative.dev/pkg/apis/duck"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/logging"
@@ -45,14 +50,25 @@ func (pb *PolicyPodspecableBinding) Do(ctx context.Context, ps *duckv1.WithPod)
 		},
 	}
 	patch = append(patch, addEnvs(ps, envs)...)
-	patch = append(patch, addAnnotation(ps, "security.knative.dev/policyGeneration", binding.GetAnnotations()["security.knative.dev/policyGeneration"])...)
+	// Without a policy generation the policy is updated in place and the
+	// pods are not rolled.
+	if gen := binding.GetAnnotations()[PolicyGenerationAnnotationKey]; gen != "" {
+		patch = append(patch, addAnnotation(ps, PolicyGenerationAnnotationKey, gen)...)
+	}
 
 	if binding.Spec.AgentSpec == nil {
 		return patch
 	}
 
+	agent := binding.Spec.AgentSpec.Container
+	if binding.Spec.AgentSpec.Proxy != nil {
+		var proxyPatch duck.JSONPatch
+		agent, proxyPatch = proxyContainerPort(ps, agent, binding.Spec.AgentSpec.Proxy.Port)
+		patch = append(patch, proxyPatch...)
+	}
+
 	patch = append(patch, addVolumes(ps, binding.Spec.AgentSpec.Volumes)...)
-	patch = append(patch, addContainer(ps, binding.Spec.AgentSpec.Container)...)
+	patch = append(patch, addContainer(ps, agent)...)
 	return patch
 }
 
@@ -66,12 +82,20 @@ func (pb *PolicyPodspecableBinding) Undo(ctx context.Context, ps *duckv1.WithPod
 
 	var patch duck.JSONPatch
 	if ps.Spec.Template.Annotations != nil {
-		if _, ok := ps.Spec.Template.Annotations["security.knative.dev/policyGeneration"]; ok {
-			patch = append(patch, removeAnnotation(ps, "security.knative.dev/policyGeneration")...)
+		if _, ok := ps.Spec.Template.Annotations[PolicyGenerationAnnotationKey]; ok {
+			patch = append(patch, removeAnnotation(ps, PolicyGenerationAnnotationKey)...)
+		}
+	}
+
+	if ps.Spec.Template.Annotations != nil {
+		if _, ok := ps.Spec.Template.Annotations[proxiedPortAnnotationKey]; ok {
+			patch = append(patch, restoreContainerPort(ps)...)
 		}
 	}
 
-	envs := []string{"K_POLICY_DECIDER"}
+	// K_POLICY_CHECK_PAYLOAD is left by the v1alpha1 PolicyBindings the
+	// workload may be migrated from.
+	envs := []string{"K_POLICY_DECIDER", "K_POLICY_CHECK_PAYLOAD"}
 
 	// This has problem when previously there is agent spec and then removed.
 	if binding.Spec.AgentSpec == nil {
@@ -83,6 +107,313 @@ func (pb *PolicyPodspecableBinding) Undo(ctx context.Context, ps *duckv1.WithPod
 	return append(patch, removeEnvs(ps, envs)...)
 }
 
+// ConflictsWith implements psbinding.ConflictDetector. A pod only has one
+// decider and one set of names for containers and volumes, so bindings
+// injecting different ones can't be applied to the same workload. Multiple
+// policies for a workload should be composed in one HTTPPolicyBinding instead.
+func (pb *PolicyPodspecableBinding) ConflictsWith(other duck.Bindable) error {
+	o, ok := other.(*PolicyPodspecableBinding)
+	if !ok {
+		return nil
+	}
+	if pb.Spec.DeciderURI != o.Spec.DeciderURI {
+		return fmt.Errorf("decider %q differs from %q", pb.Spec.DeciderURI, o.Spec.DeciderURI)
+	}
+
+	a, b := pb.Spec.AgentSpec, o.Spec.AgentSpec
+	if a == nil || b == nil || equality.Semantic.DeepEqual(a, b) {
+		return nil
+	}
+	sameAgent := a.Container.Name == b.Container.Name
+	if sameAgent && !equality.Semantic.DeepEqual(a.Container, b.Container) {
+		return fmt.Errorf("agent container %q is injected with a different spec", a.Container.Name)
+	}
+	for _, va := range a.Volumes {
+		for _, vb := range b.Volumes {
+			if va.Name == vb.Name && !equality.Semantic.DeepEqual(va, vb) {
+				return fmt.Errorf("volume %q is injected with a different source", va.Name)
+			}
+		}
+	}
+	if (sameAgent && !equality.Semantic.DeepEqual(a.Proxy, b.Proxy)) || (!sameAgent && a.Proxy != nil && b.Proxy != nil) {
+		return errors.New("only one agent can proxy the user container")
+	}
+	return nil
+}
+
+// IsFallback implements psbinding.Fallback. Bindings for all the workloads of
+// a namespace yield to the bindings for specific workloads.
+func (pb *PolicyPodspecableBinding) IsFallback() bool {
+	return pb.GetAnnotations()[FallbackAnnotationKey] == "true"
+}
+
+const (
+	// PolicyGenerationAnnotationKey is stamped onto the pod template so that
+	// pods are rolled when the policy changes.
+	PolicyGenerationAnnotationKey = "security.knative.dev/policyGeneration"
+
+	// FallbackAnnotationKey marks the PolicyPodspecableBindings only applied
+	// to the workloads no other PolicyPodspecableBinding conflicts on.
+	FallbackAnnotationKey = "security.knative.dev/fallback"
+
+	proxiedPortAnnotationKey = "security.knative.dev/proxiedPort"
+)
+
+// proxiedPort records the user container port taken over by the agent proxy,
+// and what was changed to move the user container away from it, so it can be
+// restored on Undo.
+type proxiedPort struct {
+	Container string               `json:"container"`
+	Port      corev1.ContainerPort `json:"port"`
+	// PortEnv is the PORT env of the user container before it was moved.
+	PortEnv *corev1.EnvVar `json:"portEnv,omitempty"`
+	// Probes of the user container before they were moved to the new port.
+	LivenessProbe  *corev1.Probe `json:"livenessProbe,omitempty"`
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+}
+
+// knativeRevisionLabelKey labels the pods of Knative revisions.
+const knativeRevisionLabelKey = "serving.knative.dev/revision"
+
+// ValidateSubject implements psbinding.SubjectValidator. The agent proxy can't
+// take over a port the agent listens on itself, nor move Knative revisions,
+// as Knative sets the PORT env itself.
+func (pb *PolicyPodspecableBinding) ValidateSubject(ctx context.Context, ps *duckv1.WithPod) error {
+	binding := GetBinding(ctx)
+	if binding == nil || binding.Spec.AgentSpec == nil || binding.Spec.AgentSpec.Proxy == nil {
+		return nil
+	}
+	if _, ok := ps.Spec.Template.Labels[knativeRevisionLabelKey]; ok {
+		return errors.New("the agent proxy can't move the port of Knative revisions")
+	}
+
+	// Validate the subject as it is without the binding.
+	orig := ps.DeepCopy()
+	pb.Undo(ctx, orig)
+	agent := binding.Spec.AgentSpec.Container
+	i := proxiedContainer(orig, agent.Name)
+	if i < 0 {
+		return nil
+	}
+	c := orig.Spec.Template.Spec.Containers[i]
+	for _, p := range agent.Ports {
+		if p.ContainerPort == c.Ports[0].ContainerPort {
+			return fmt.Errorf("port %d of container %q is used by the policy agent", p.ContainerPort, c.Name)
+		}
+	}
+	return nil
+}
+
+// proxiedContainer returns the index of the container whose first port the
+// agent proxy takes over, or -1 if no container has a port.
+func proxiedContainer(ps *duckv1.WithPod, agentName string) int {
+	for i, c := range ps.Spec.Template.Spec.Containers {
+		if c.Name != agentName && len(c.Ports) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// proxyContainerPort moves the first port of the user container to the agent
+// container, so the agent proxy listens on the original port, and moves the
+// user container to userPort, or the next port no container declares, through
+// the PORT env. Requests to the original port, by name or by number, can't
+// bypass the proxy, so the agent's own ports named like it are left unnamed.
+// If the user container ignores PORT, the agent can't listen on the original
+// port and the pod never gets ready. It returns the agent container to inject.
+func proxyContainerPort(ps *duckv1.WithPod, agent corev1.Container, userPort int32) (corev1.Container, duck.JSONPatch) {
+	var patch duck.JSONPatch
+	spec := ps.Spec.Template.Spec
+	if i := proxiedContainer(ps, agent.Name); i >= 0 {
+		c := spec.Containers[i]
+		taken := map[int32]bool{}
+		for _, p := range agent.Ports {
+			taken[p.ContainerPort] = true
+		}
+		for _, other := range spec.Containers {
+			for _, p := range other.Ports {
+				taken[p.ContainerPort] = true
+			}
+		}
+		for taken[userPort] {
+			userPort++
+		}
+
+		record := proxiedPort{Container: c.Name, Port: c.Ports[0]}
+		spec.Containers[i].Ports = c.Ports[1:]
+		if len(spec.Containers[i].Ports) == 0 {
+			spec.Containers[i].Ports = nil
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "remove",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/ports", i),
+			})
+		} else {
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "replace",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/ports", i),
+				Value:     spec.Containers[i].Ports,
+			})
+		}
+
+		env := []corev1.EnvVar{{Name: "PORT", Value: strconv.Itoa(int(userPort))}}
+		for _, e := range c.Env {
+			if e.Name == "PORT" {
+				record.PortEnv = e.DeepCopy()
+				continue
+			}
+			env = append(env, e)
+		}
+		spec.Containers[i].Env = env
+		patch = append(patch, jsonpatch.Operation{
+			Operation: "replace",
+			Path:      fmt.Sprintf("/spec/template/spec/containers/%d/env", i),
+			Value:     env,
+		})
+
+		// The kubelet probes the user container directly, not through the
+		// policy.
+		if p := moveProbe(c.LivenessProbe, record.Port, userPort); p != nil {
+			record.LivenessProbe = c.LivenessProbe
+			spec.Containers[i].LivenessProbe = p
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "replace",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/livenessProbe", i),
+				Value:     p,
+			})
+		}
+		if p := moveProbe(c.ReadinessProbe, record.Port, userPort); p != nil {
+			record.ReadinessProbe = c.ReadinessProbe
+			spec.Containers[i].ReadinessProbe = p
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "replace",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/readinessProbe", i),
+				Value:     p,
+			})
+		}
+		ps.Spec.Template.Spec = spec
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			// Should never happen.
+			return agent, nil
+		}
+		patch = append(patch, addAnnotation(ps, proxiedPortAnnotationKey, string(b))...)
+
+		proxy := *agent.DeepCopy()
+		for j, p := range proxy.Ports {
+			if record.Port.Name != "" && p.Name == record.Port.Name {
+				proxy.Ports[j].Name = ""
+			}
+		}
+		proxy.Ports = append(proxy.Ports, record.Port)
+		proxy.Env = append(proxy.Env,
+			corev1.EnvVar{
+				Name:  "PROXY_PORT",
+				Value: strconv.Itoa(int(record.Port.ContainerPort)),
+			},
+			corev1.EnvVar{
+				Name:  "PROXY_TARGET",
+				Value: fmt.Sprintf("http://127.0.0.1:%d", userPort),
+			},
+		)
+		return proxy, patch
+	}
+	return agent, patch
+}
+
+// moveProbe returns a copy of the probe pointed to userPort if it probes the
+// original port, nil otherwise.
+func moveProbe(probe *corev1.Probe, original corev1.ContainerPort, userPort int32) *corev1.Probe {
+	if probe == nil {
+		return nil
+	}
+	targets := func(port intstr.IntOrString) bool {
+		if port.Type == intstr.String {
+			return original.Name != "" && port.StrVal == original.Name
+		}
+		return port.IntVal == original.ContainerPort
+	}
+	moved := probe.DeepCopy()
+	switch {
+	case moved.HTTPGet != nil && targets(moved.HTTPGet.Port):
+		moved.HTTPGet.Port = intstr.FromInt(int(userPort))
+	case moved.TCPSocket != nil && targets(moved.TCPSocket.Port):
+		moved.TCPSocket.Port = intstr.FromInt(int(userPort))
+	default:
+		return nil
+	}
+	return moved
+}
+
+// restoreContainerPort reverts proxyContainerPort.
+func restoreContainerPort(ps *duckv1.WithPod) (patch duck.JSONPatch) {
+	var record proxiedPort
+	err := json.Unmarshal([]byte(ps.Spec.Template.Annotations[proxiedPortAnnotationKey]), &record)
+	patch = append(patch, removeAnnotation(ps, proxiedPortAnnotationKey)...)
+	if err != nil {
+		return patch
+	}
+
+	spec := ps.Spec.Template.Spec
+	for i, c := range spec.Containers {
+		if c.Name != record.Container {
+			continue
+		}
+		if len(c.Ports) == 0 {
+			spec.Containers[i].Ports = []corev1.ContainerPort{record.Port}
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "add",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/ports", i),
+				Value:     spec.Containers[i].Ports,
+			})
+		} else {
+			spec.Containers[i].Ports = append([]corev1.ContainerPort{record.Port}, c.Ports...)
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "replace",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/ports", i),
+				Value:     spec.Containers[i].Ports,
+			})
+		}
+
+		var env []corev1.EnvVar
+		if record.PortEnv != nil {
+			env = append(env, *record.PortEnv)
+		}
+		for _, e := range c.Env {
+			if e.Name != "PORT" {
+				env = append(env, e)
+			}
+		}
+		spec.Containers[i].Env = env
+		patch = append(patch, jsonpatch.Operation{
+			Operation: "replace",
+			Path:      fmt.Sprintf("/spec/template/spec/containers/%d/env", i),
+			Value:     env,
+		})
+
+		if record.LivenessProbe != nil {
+			spec.Containers[i].LivenessProbe = record.LivenessProbe
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "replace",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/livenessProbe", i),
+				Value:     record.LivenessProbe,
+			})
+		}
+		if record.ReadinessProbe != nil {
+			spec.Containers[i].ReadinessProbe = record.ReadinessProbe
+			patch = append(patch, jsonpatch.Operation{
+				Operation: "replace",
+				Path:      fmt.Sprintf("/spec/template/spec/containers/%d/readinessProbe", i),
+				Value:     record.ReadinessProbe,
+			})
+		}
+		break
+	}
+	ps.Spec.Template.Spec = spec
+	return patch
+}
+
 func removeAnnotation(ps *duckv1.WithPod, key string) (patch duck.JSONPatch) {
 	delete(ps.Spec.Template.Annotations, key)
 	patch = append(patch, jsonpatch.Operation{
@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func proxyBinding() *PolicyPodspecableBinding {
+	return &PolicyPodspecableBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "binding"},
+		Spec: PolicyPodspecableBindingSpec{
+			DeciderURI: "http://localhost:8090",
+			AgentSpec: &PolicyAgentSpec{
+				Proxy: &PolicyProxySpec{Port: 8091},
+				Volumes: []corev1.Volume{{
+					Name: "open-policy",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "binding"},
+						},
+					},
+				}},
+				Container: corev1.Container{
+					Name:  "kn-policy-agent",
+					Image: "agent",
+					Ports: []corev1.ContainerPort{
+						{Name: "http", ContainerPort: 8090},
+						{Name: "grpc-ext-authz", ContainerPort: 9191},
+					},
+				},
+			},
+		},
+	}
+}
+
+func userPod(ports ...corev1.ContainerPort) *duckv1.WithPod {
+	return &duckv1.WithPod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "workload"},
+		Spec: duckv1.WithPodSpec{
+			Template: duckv1.PodSpecable{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "workload"},
+					Annotations: map[string]string{"foo": "bar"},
+				},
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{
+						Name:         "data",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+					Containers: []corev1.Container{{
+						Name:  "user",
+						Image: "user",
+						Ports: ports,
+						Env: []corev1.EnvVar{
+							{Name: "PORT", Value: "8080"},
+							{Name: "FOO", Value: "bar"},
+						},
+						ReadinessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString(ports[0].Name)},
+							},
+						},
+						LivenessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(8080)},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// applyPatch applies the patch to a copy of the object, the way the API server
+// applies the patch of the webhook.
+func applyPatch(t *testing.T, ps *duckv1.WithPod, patch duck.JSONPatch) *duckv1.WithPod {
+	t.Helper()
+	orig, err := json.Marshal(ps)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	p, err := jsonpatch.DecodePatch(raw)
+	if err != nil {
+		t.Fatalf("DecodePatch() = %v", err)
+	}
+	patched, err := p.Apply(orig)
+	if err != nil {
+		t.Fatalf("Apply() = %v\n%s", err, raw)
+	}
+	ret := &duckv1.WithPod{}
+	if err := json.Unmarshal(patched, ret); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	return ret
+}
+
+func findContainer(t *testing.T, ps *duckv1.WithPod, name string) corev1.Container {
+	t.Helper()
+	for _, c := range ps.Spec.Template.Spec.Containers {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("Container %q not found", name)
+	return corev1.Container{}
+}
+
+func envValue(c corev1.Container, name string) string {
+	for _, e := range c.Env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestProxyContainerPortRoundTrip(t *testing.T) {
+	tests := []struct {
+		name           string
+		ports          []corev1.ContainerPort
+		wantUserPorts  []corev1.ContainerPort
+		wantAgentPorts []corev1.ContainerPort
+		wantPort       int32
+	}{{
+		name:          "single port",
+		ports:         []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+		wantUserPorts: nil,
+		wantAgentPorts: []corev1.ContainerPort{
+			{ContainerPort: 8090},
+			{Name: "grpc-ext-authz", ContainerPort: 9191},
+			{Name: "http", ContainerPort: 8080},
+		},
+		wantPort: 8091,
+	}, {
+		name: "taken proxied user port",
+		ports: []corev1.ContainerPort{
+			{Name: "web", ContainerPort: 8080},
+			{Name: "admin", ContainerPort: 8091},
+		},
+		wantUserPorts: []corev1.ContainerPort{{Name: "admin", ContainerPort: 8091}},
+		wantAgentPorts: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: 8090},
+			{Name: "grpc-ext-authz", ContainerPort: 9191},
+			{Name: "web", ContainerPort: 8080},
+		},
+		wantPort: 8092,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pb := proxyBinding()
+			ctx := WithBinding(context.Background(), pb)
+			orig := userPod(tc.ports...)
+
+			bound := orig.DeepCopy()
+			patch := pb.Do(ctx, bound)
+			if diff := cmp.Diff(bound, applyPatch(t, orig, patch)); diff != "" {
+				t.Errorf("Do() patch differs from the mutation (-mutated, +patched) = %s", diff)
+			}
+
+			user := findContainer(t, bound, "user")
+			if diff := cmp.Diff(tc.wantUserPorts, user.Ports); diff != "" {
+				t.Errorf("User ports (-want, +got) = %s", diff)
+			}
+			wantPort := intstr.FromInt(int(tc.wantPort))
+			if got := envValue(user, "PORT"); got != wantPort.String() {
+				t.Errorf("PORT = %q, want %q", got, wantPort.String())
+			}
+			if got := user.ReadinessProbe.HTTPGet.Port; got != wantPort {
+				t.Errorf("Readiness probe port = %v, want %v", got.String(), wantPort.String())
+			}
+			if got := user.LivenessProbe.TCPSocket.Port; got != wantPort {
+				t.Errorf("Liveness probe port = %v, want %v", got.String(), wantPort.String())
+			}
+
+			agent := findContainer(t, bound, "kn-policy-agent")
+			if diff := cmp.Diff(tc.wantAgentPorts, agent.Ports); diff != "" {
+				t.Errorf("Agent ports (-want, +got) = %s", diff)
+			}
+			if got, want := envValue(agent, "PROXY_PORT"), "8080"; got != want {
+				t.Errorf("PROXY_PORT = %q, want %q", got, want)
+			}
+			if got, want := envValue(agent, "PROXY_TARGET"), "http://127.0.0.1:"+wantPort.String(); got != want {
+				t.Errorf("PROXY_TARGET = %q, want %q", got, want)
+			}
+
+			// Binding it again changes nothing.
+			rebound := bound.DeepCopy()
+			pb.Do(ctx, rebound)
+			if diff := cmp.Diff(bound, rebound); diff != "" {
+				t.Errorf("Do() again (-want, +got) = %s", diff)
+			}
+
+			unbound := bound.DeepCopy()
+			patch = pb.Undo(ctx, unbound)
+			if diff := cmp.Diff(unbound, applyPatch(t, bound, patch)); diff != "" {
+				t.Errorf("Undo() patch differs from the mutation (-mutated, +patched) = %s", diff)
+			}
+			if diff := cmp.Diff(orig, unbound); diff != "" {
+				t.Errorf("Undo() (-want, +got) = %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		binding func(*PolicyPodspecableBinding)
+		pod     func(*duckv1.WithPod)
+		wantErr bool
+	}{{
+		name: "free port",
+	}, {
+		name: "port of the agent",
+		pod: func(ps *duckv1.WithPod) {
+			ps.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = 9191
+		},
+		wantErr: true,
+	}, {
+		name: "port of the agent without proxy",
+		binding: func(pb *PolicyPodspecableBinding) {
+			pb.Spec.AgentSpec.Proxy = nil
+		},
+		pod: func(ps *duckv1.WithPod) {
+			ps.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = 9191
+		},
+	}, {
+		name: "knative revision",
+		pod: func(ps *duckv1.WithPod) {
+			ps.Spec.Template.Labels[knativeRevisionLabelKey] = "rev-1"
+		},
+		wantErr: true,
+	}, {
+		name: "already bound",
+		pod: func(ps *duckv1.WithPod) {
+			pb := proxyBinding()
+			pb.Do(WithBinding(context.Background(), pb), ps)
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pb := proxyBinding()
+			if tc.binding != nil {
+				tc.binding(pb)
+			}
+			ps := userPod(corev1.ContainerPort{Name: "http", ContainerPort: 8080})
+			if tc.pod != nil {
+				tc.pod(ps)
+			}
+			err := pb.ValidateSubject(WithBinding(context.Background(), pb), ps)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateSubject() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
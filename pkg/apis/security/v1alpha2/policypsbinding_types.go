@@ -55,6 +55,22 @@ type PolicyAgentSpec struct {
 
 	// Container to inject as the agent sidecar.
 	Container corev1.Container `json:"container,omitempty"`
+
+	// Proxy makes the agent front the user container as a reverse proxy.
+	// The first port of the user container is moved to the agent so only
+	// requests allowed by the policy reach the user container, which is told
+	// to listen on another port through the PORT env.
+	// +optional
+	Proxy *PolicyProxySpec `json:"proxy,omitempty"`
+}
+
+type PolicyProxySpec struct {
+	// Port is the port the user container is moved to, or the next port no
+	// container of the pod declares. The agent proxy forwards the allowed
+	// requests to it on localhost. It isn't declared by any container, so
+	// Services can't target it; user containers should only listen on
+	// localhost for it not to be reachable on the pod IP.
+	Port int32 `json:"port"`
 }
 
 // PolicyPodspecableBindingStatus is the status of the binding.
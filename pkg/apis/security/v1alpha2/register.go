@@ -46,9 +46,13 @@ var (
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&HTTPPolicy{},
+		&ClusterHTTPPolicy{},
+		&EventPolicy{},
 		&HTTPPolicyBinding{},
 		&PolicyPodspecableBinding{},
 		&HTTPPolicyList{},
+		&ClusterHTTPPolicyList{},
+		&EventPolicyList{},
 		&HTTPPolicyBindingList{},
 		&PolicyPodspecableBindingList{},
 	)
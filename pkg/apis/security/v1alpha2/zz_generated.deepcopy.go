@@ -22,10 +22,203 @@ package v1alpha2
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	tracker "knative.dev/pkg/tracker"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterHTTPPolicy) DeepCopyInto(out *ClusterHTTPPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHTTPPolicy.
+func (in *ClusterHTTPPolicy) DeepCopy() *ClusterHTTPPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterHTTPPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterHTTPPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterHTTPPolicyList) DeepCopyInto(out *ClusterHTTPPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterHTTPPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHTTPPolicyList.
+func (in *ClusterHTTPPolicyList) DeepCopy() *ClusterHTTPPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterHTTPPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterHTTPPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventAttributeMatch) DeepCopyInto(out *EventAttributeMatch) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotValues != nil {
+		in, out := &in.NotValues, &out.NotValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventAttributeMatch.
+func (in *EventAttributeMatch) DeepCopy() *EventAttributeMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(EventAttributeMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventPolicy) DeepCopyInto(out *EventPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventPolicy.
+func (in *EventPolicy) DeepCopy() *EventPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EventPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EventPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventPolicyList) DeepCopyInto(out *EventPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EventPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventPolicyList.
+func (in *EventPolicyList) DeepCopy() *EventPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(EventPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EventPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventPolicySpec) DeepCopyInto(out *EventPolicySpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]EventRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventPolicySpec.
+func (in *EventPolicySpec) DeepCopy() *EventPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EventPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventRule) DeepCopyInto(out *EventRule) {
+	*out = *in
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make([]EventAttributeMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventRule.
+func (in *EventRule) DeepCopy() *EventRule {
+	if in == nil {
+		return nil
+	}
+	out := new(EventRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPPolicy) DeepCopyInto(out *HTTPPolicy) {
 	*out = *in
@@ -122,11 +315,21 @@ func (in *HTTPPolicyBindingSpec) DeepCopyInto(out *HTTPPolicyBindingSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = new(WorkloadsSubject)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Policy != nil {
 		in, out := &in.Policy, &out.Policy
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -149,6 +352,16 @@ func (in *HTTPPolicyBindingStatus) DeepCopyInto(out *HTTPPolicyBindingStatus) {
 		*out = new(tracker.Reference)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ResolvedPolicies != nil {
+		in, out := &in.ResolvedPolicies, &out.ResolvedPolicies
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.GeneratedObjects != nil {
+		in, out := &in.GeneratedObjects, &out.GeneratedObjects
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -250,6 +463,11 @@ func (in *KeyValueMatch) DeepCopyInto(out *KeyValueMatch) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NotValues != nil {
+		in, out := &in.NotValues, &out.NotValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -271,16 +489,31 @@ func (in *Operation) DeepCopyInto(out *Operation) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NotHosts != nil {
+		in, out := &in.NotHosts, &out.NotHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Paths != nil {
 		in, out := &in.Paths, &out.Paths
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NotPaths != nil {
+		in, out := &in.NotPaths, &out.NotPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Methods != nil {
 		in, out := &in.Methods, &out.Methods
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NotMethods != nil {
+		in, out := &in.NotMethods, &out.NotMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -305,6 +538,11 @@ func (in *PolicyAgentSpec) DeepCopyInto(out *PolicyAgentSpec) {
 		}
 	}
 	in.Container.DeepCopyInto(&out.Container)
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(PolicyProxySpec)
+		**out = **in
+	}
 	return
 }
 
@@ -418,6 +656,22 @@ func (in *PolicyPodspecableBindingStatus) DeepCopy() *PolicyPodspecableBindingSt
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyProxySpec) DeepCopyInto(out *PolicyProxySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyProxySpec.
+func (in *PolicyProxySpec) DeepCopy() *PolicyProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RequestAuth) DeepCopyInto(out *RequestAuth) {
 	*out = *in
@@ -449,6 +703,13 @@ func (in *RequestAuth) DeepCopy() *RequestAuth {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuleSpec) DeepCopyInto(out *RuleSpec) {
 	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]Source, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Auth.DeepCopyInto(&out.Auth)
 	if in.Headers != nil {
 		in, out := &in.Headers, &out.Headers
@@ -477,6 +738,37 @@ func (in *RuleSpec) DeepCopy() *RuleSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Source) DeepCopyInto(out *Source) {
+	*out = *in
+	if in.IPBlocks != nil {
+		in, out := &in.IPBlocks, &out.IPBlocks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Principals != nil {
+		in, out := &in.Principals, &out.Principals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Source.
+func (in *Source) DeepCopy() *Source {
+	if in == nil {
+		return nil
+	}
+	out := new(Source)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerRule) DeepCopyInto(out *TriggerRule) {
 	*out = *in
@@ -502,3 +794,24 @@ func (in *TriggerRule) DeepCopy() *TriggerRule {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadsSubject) DeepCopyInto(out *WorkloadsSubject) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadsSubject.
+func (in *WorkloadsSubject) DeepCopy() *WorkloadsSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadsSubject)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -28,6 +28,14 @@ type FakeSecurityV1alpha2 struct {
 	*testing.Fake
 }
 
+func (c *FakeSecurityV1alpha2) ClusterHTTPPolicies() v1alpha2.ClusterHTTPPolicyInterface {
+	return &FakeClusterHTTPPolicies{c}
+}
+
+func (c *FakeSecurityV1alpha2) EventPolicies(namespace string) v1alpha2.EventPolicyInterface {
+	return &FakeEventPolicies{c, namespace}
+}
+
 func (c *FakeSecurityV1alpha2) HTTPPolicies(namespace string) v1alpha2.HTTPPolicyInterface {
 	return &FakeHTTPPolicies{c, namespace}
 }
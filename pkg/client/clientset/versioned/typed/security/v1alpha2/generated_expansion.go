@@ -18,6 +18,10 @@ limitations under the License.
 
 package v1alpha2
 
+type ClusterHTTPPolicyExpansion interface{}
+
+type EventPolicyExpansion interface{}
+
 type HTTPPolicyExpansion interface{}
 
 type HTTPPolicyBindingExpansion interface{}
@@ -26,6 +26,8 @@ import (
 
 type SecurityV1alpha2Interface interface {
 	RESTClient() rest.Interface
+	ClusterHTTPPoliciesGetter
+	EventPoliciesGetter
 	HTTPPoliciesGetter
 	HTTPPolicyBindingsGetter
 	PolicyPodspecableBindingsGetter
@@ -36,6 +38,14 @@ type SecurityV1alpha2Client struct {
 	restClient rest.Interface
 }
 
+func (c *SecurityV1alpha2Client) ClusterHTTPPolicies() ClusterHTTPPolicyInterface {
+	return newClusterHTTPPolicies(c)
+}
+
+func (c *SecurityV1alpha2Client) EventPolicies(namespace string) EventPolicyInterface {
+	return newEventPolicies(c, namespace)
+}
+
 func (c *SecurityV1alpha2Client) HTTPPolicies(namespace string) HTTPPolicyInterface {
 	return newHTTPPolicies(c, namespace)
 }
@@ -64,6 +64,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Security().V1alpha1().PolicyBindings().Informer()}, nil
 
 		// Group=security.knative.dev, Version=v1alpha2
+	case v1alpha2.SchemeGroupVersion.WithResource("clusterhttppolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Security().V1alpha2().ClusterHTTPPolicies().Informer()}, nil
+	case v1alpha2.SchemeGroupVersion.WithResource("eventpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Security().V1alpha2().EventPolicies().Informer()}, nil
 	case v1alpha2.SchemeGroupVersion.WithResource("httppolicies"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Security().V1alpha2().HTTPPolicies().Informer()}, nil
 	case v1alpha2.SchemeGroupVersion.WithResource("httppolicybindings"):
@@ -24,6 +24,10 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterHTTPPolicies returns a ClusterHTTPPolicyInformer.
+	ClusterHTTPPolicies() ClusterHTTPPolicyInformer
+	// EventPolicies returns a EventPolicyInformer.
+	EventPolicies() EventPolicyInformer
 	// HTTPPolicies returns a HTTPPolicyInformer.
 	HTTPPolicies() HTTPPolicyInformer
 	// HTTPPolicyBindings returns a HTTPPolicyBindingInformer.
@@ -43,6 +47,16 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterHTTPPolicies returns a ClusterHTTPPolicyInformer.
+func (v *version) ClusterHTTPPolicies() ClusterHTTPPolicyInformer {
+	return &clusterHTTPPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// EventPolicies returns a EventPolicyInformer.
+func (v *version) EventPolicies() EventPolicyInformer {
+	return &eventPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // HTTPPolicies returns a HTTPPolicyInformer.
 func (v *version) HTTPPolicies() HTTPPolicyInformer {
 	return &hTTPPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
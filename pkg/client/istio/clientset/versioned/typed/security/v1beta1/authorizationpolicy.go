@@ -21,8 +21,8 @@ package v1beta1
 import (
 	"time"
 
+	v1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
 	scheme "github.com/yolocs/knative-policy-binding/pkg/client/istio/clientset/versioned/scheme"
-	v1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -19,7 +19,7 @@ limitations under the License.
 package fake
 
 import (
-	v1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	v1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
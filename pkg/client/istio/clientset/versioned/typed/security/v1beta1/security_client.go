@@ -19,14 +19,15 @@ limitations under the License.
 package v1beta1
 
 import (
+	v1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
 	"github.com/yolocs/knative-policy-binding/pkg/client/istio/clientset/versioned/scheme"
-	v1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 	rest "k8s.io/client-go/rest"
 )
 
 type SecurityV1beta1Interface interface {
 	RESTClient() rest.Interface
 	AuthorizationPoliciesGetter
+	RequestAuthenticationsGetter
 }
 
 // SecurityV1beta1Client is used to interact with features provided by the security.istio.io group.
@@ -38,6 +39,10 @@ func (c *SecurityV1beta1Client) AuthorizationPolicies(namespace string) Authoriz
 	return newAuthorizationPolicies(c, namespace)
 }
 
+func (c *SecurityV1beta1Client) RequestAuthentications(namespace string) RequestAuthenticationInterface {
+	return newRequestAuthentications(c, namespace)
+}
+
 // NewForConfig creates a new SecurityV1beta1Client for the given config.
 func NewForConfig(c *rest.Config) (*SecurityV1beta1Client, error) {
 	config := *c
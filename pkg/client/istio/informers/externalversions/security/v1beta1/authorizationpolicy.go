@@ -21,10 +21,10 @@ package v1beta1
 import (
 	time "time"
 
+	securityv1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
 	versioned "github.com/yolocs/knative-policy-binding/pkg/client/istio/clientset/versioned"
 	internalinterfaces "github.com/yolocs/knative-policy-binding/pkg/client/istio/informers/externalversions/internalinterfaces"
 	v1beta1 "github.com/yolocs/knative-policy-binding/pkg/client/istio/listers/security/v1beta1"
-	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -18,6 +18,18 @@ limitations under the License.
 
 package v1alpha2
 
+// ClusterHTTPPolicyListerExpansion allows custom methods to be added to
+// ClusterHTTPPolicyLister.
+type ClusterHTTPPolicyListerExpansion interface{}
+
+// EventPolicyListerExpansion allows custom methods to be added to
+// EventPolicyLister.
+type EventPolicyListerExpansion interface{}
+
+// EventPolicyNamespaceListerExpansion allows custom methods to be added to
+// EventPolicyNamespaceLister.
+type EventPolicyNamespaceListerExpansion interface{}
+
 // HTTPPolicyListerExpansion allows custom methods to be added to
 // HTTPPolicyLister.
 type HTTPPolicyListerExpansion interface{}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events knows how the attributes of CloudEvents, named as in the
+// policy rules, are carried over HTTP.
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cloudevents/sdk-go/pkg/cloudevents"
+	"github.com/cloudevents/sdk-go/pkg/cloudevents/transport"
+	cehttp "github.com/cloudevents/sdk-go/pkg/cloudevents/transport/http"
+)
+
+var (
+	codecV03 *cehttp.CodecV03 = &cehttp.CodecV03{}
+	codecV1  *cehttp.CodecV1  = &cehttp.CodecV1{}
+)
+
+func coreSetters(e *cloudevents.Event) map[string]func(string) {
+	return map[string]func(string){
+		"id":          e.SetID,
+		"type":        e.SetType,
+		"source":      e.SetSource,
+		"subject":     e.SetSubject,
+		"dataschema":  e.SetDataSchema,
+		"contenttype": e.SetDataContentType,
+		"encoding":    e.SetDataContentEncoding,
+	}
+}
+
+// SetAttribute sets the attribute of the event, named as in the rules. The
+// names of extensions are prefixed with "ext:". It returns false if there's
+// no such attribute.
+func SetAttribute(e *cloudevents.Event, name, value string) bool {
+	if strings.HasPrefix(name, "ext:") {
+		e.SetExtension(strings.TrimPrefix(name, "ext:"), value)
+		return true
+	}
+	setFunc, ok := coreSetters(e)[strings.ToLower(name)]
+	if ok {
+		setFunc(value)
+	}
+	return ok
+}
+
+// EncodeHeader returns the headers of the event in binary mode.
+func EncodeHeader(e cloudevents.Event) (http.Header, error) {
+	var codec transport.Codec
+	switch e.SpecVersion() {
+	case cloudevents.CloudEventsVersionV1:
+		codec = codecV1
+	case cloudevents.CloudEventsVersionV03:
+		codec = codecV03
+	default:
+		return nil, fmt.Errorf("unsupported spec version %q", e.SpecVersion())
+	}
+	msg, err := codec.Encode(context.Background(), e)
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*cehttp.Message).Header, nil
+}
+
+// AttributeHeader returns the header carrying the attribute, named as in the
+// rules, of the events of the spec version in binary mode.
+func AttributeHeader(version, name string) (string, bool) {
+	e := &cloudevents.Event{}
+	e.SetSpecVersion(version)
+	if !SetAttribute(e, name, name) {
+		return "", false
+	}
+
+	header, err := EncodeHeader(*e)
+	if err != nil {
+		return "", false
+	}
+	for _, k := range SortedHeaderKeys(header) {
+		for _, v := range header[k] {
+			if v == name {
+				return k, true
+			}
+		}
+	}
+	return "", false
+}
+
+// SortedHeaderKeys returns the keys of the header in order.
+func SortedHeaderKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httppolicy
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+)
+
+// Compose combines the policy specs into one spec. The ALLOW rules are
+// combined as specified by the composition. The DENY and AUDIT rules of every
+// policy are kept as is, so a DENY rule of any policy denies the requests it
+// matches even if another policy allows them with AnyOf. Istio
+// AuthorizationPolicies can't scope DENY rules to a policy, so neither binding
+// class does. Only one JWT config can be verified, so the policies must
+// agree on it. Rego rules can't be combined, so they are only allowed in a
+// single policy.
+func Compose(composition v1alpha2.PolicyComposition, specs []*v1alpha2.HTTPPolicySpec) (*v1alpha2.HTTPPolicySpec, error) {
+	if len(specs) == 1 {
+		return specs[0].DeepCopy(), nil
+	}
+
+	ret := &v1alpha2.HTTPPolicySpec{}
+	for _, s := range specs {
+		if s.Rego != "" {
+			return nil, errors.New("policies with rego can't be composed")
+		}
+		if equality.Semantic.DeepEqual(s.JWT, v1alpha2.JWTSpec{}) {
+			continue
+		}
+		if !equality.Semantic.DeepEqual(ret.JWT, v1alpha2.JWTSpec{}) && !equality.Semantic.DeepEqual(ret.JWT, s.JWT) {
+			return nil, errors.New("policies have conflicting JWT settings")
+		}
+		ret.JWT = *s.JWT.DeepCopy()
+	}
+
+	var allows []v1alpha2.RuleSpec
+	if composition == v1alpha2.PolicyCompositionAnyOf {
+		allows = anyOf(specs)
+	} else {
+		allows = allOf(specs)
+	}
+	ret.Rules = append(ret.Rules, allows...)
+	for _, s := range specs {
+		for _, r := range s.Rules {
+			if !isAllow(&r) {
+				ret.Rules = append(ret.Rules, *r.DeepCopy())
+			}
+		}
+	}
+	return ret, nil
+}
+
+// anyOf returns the ALLOW rules matching the requests allowed by any policy.
+// A policy with rules but no ALLOW rules allows everything its DENY rules
+// don't deny, so then any request is allowed.
+func anyOf(specs []*v1alpha2.HTTPPolicySpec) []v1alpha2.RuleSpec {
+	var ret []v1alpha2.RuleSpec
+	for _, s := range specs {
+		allows := allowRules(s)
+		if len(allows) == 0 && len(s.Rules) > 0 {
+			return []v1alpha2.RuleSpec{{Action: v1alpha2.RuleActionAllow}}
+		}
+		ret = append(ret, allows...)
+	}
+	return ret
+}
+
+// allOf returns the ALLOW rules matching the requests allowed by all the
+// policies. A request allowed by all the policies matches an ALLOW rule of
+// each of them, so the rules are intersected pair by pair. A policy without
+// rules denies everything, and a policy with rules but no ALLOW rules doesn't
+// restrict the others.
+func allOf(specs []*v1alpha2.HTTPPolicySpec) []v1alpha2.RuleSpec {
+	var ret []v1alpha2.RuleSpec
+	restricted := false
+	for _, s := range specs {
+		if len(s.Rules) == 0 {
+			return []v1alpha2.RuleSpec{denyAllRule()}
+		}
+		allows := allowRules(s)
+		if len(allows) == 0 {
+			continue
+		}
+		if !restricted {
+			ret, restricted = allows, true
+			continue
+		}
+		var next []v1alpha2.RuleSpec
+		for _, a := range ret {
+			for _, b := range allows {
+				if r, ok := intersectRules(&a, &b); ok {
+					next = append(next, r)
+				}
+			}
+		}
+		ret = next
+	}
+	if restricted && len(ret) == 0 {
+		// No request is allowed by all the policies. Without any ALLOW rule
+		// everything would be allowed instead.
+		return []v1alpha2.RuleSpec{denyAllRule()}
+	}
+	return ret
+}
+
+func isAllow(r *v1alpha2.RuleSpec) bool {
+	return r.Action == "" || r.Action == v1alpha2.RuleActionAllow
+}
+
+func allowRules(s *v1alpha2.HTTPPolicySpec) []v1alpha2.RuleSpec {
+	var ret []v1alpha2.RuleSpec
+	for _, r := range s.Rules {
+		if isAllow(&r) {
+			ret = append(ret, *r.DeepCopy())
+		}
+	}
+	return ret
+}
+
+// denyByDefault makes the spec deny the requests none of its ALLOW rules
+// allow, even if it only has DENY or AUDIT rules. The rule matches nothing,
+// so Rego rules still allow what they match.
+func denyByDefault(spec *v1alpha2.HTTPPolicySpec) {
+	if (len(spec.Rules) > 0 || spec.Rego != "") && len(allowRules(spec)) == 0 {
+		spec.Rules = append(spec.Rules, denyAllRule())
+	}
+}
+
+// denyAllRule is an ALLOW rule matching no request.
+func denyAllRule() v1alpha2.RuleSpec {
+	return v1alpha2.RuleSpec{
+		Action:     v1alpha2.RuleActionAllow,
+		Operations: []v1alpha2.Operation{{NotPaths: []string{"*"}}},
+	}
+}
+
+// intersectRules returns a rule matching the requests both rules match. It
+// returns false if no request can match both.
+func intersectRules(a, b *v1alpha2.RuleSpec) (v1alpha2.RuleSpec, bool) {
+	ret := v1alpha2.RuleSpec{Action: v1alpha2.RuleActionAllow}
+	var ok bool
+	if ret.Auth.Principals, ok = IntersectGlobs(a.Auth.Principals, b.Auth.Principals); !ok {
+		return ret, false
+	}
+	ret.Auth.Claims = append(append(ret.Auth.Claims, a.Auth.Claims...), b.Auth.Claims...)
+	ret.Headers = append(append(ret.Headers, a.Headers...), b.Headers...)
+
+	if len(a.From) == 0 || len(b.From) == 0 {
+		ret.From = append(append(ret.From, a.From...), b.From...)
+	} else {
+		for _, sa := range a.From {
+			for _, sb := range b.From {
+				if s, ok := intersectSources(&sa, &sb); ok {
+					ret.From = append(ret.From, s)
+				}
+			}
+		}
+		if len(ret.From) == 0 {
+			return ret, false
+		}
+	}
+
+	if len(a.Operations) == 0 || len(b.Operations) == 0 {
+		ret.Operations = append(append(ret.Operations, a.Operations...), b.Operations...)
+	} else {
+		for _, oa := range a.Operations {
+			for _, ob := range b.Operations {
+				if op, ok := intersectOperations(&oa, &ob); ok {
+					ret.Operations = append(ret.Operations, op)
+				}
+			}
+		}
+		if len(ret.Operations) == 0 {
+			return ret, false
+		}
+	}
+	return *ret.DeepCopy(), true
+}
+
+func intersectSources(a, b *v1alpha2.Source) (v1alpha2.Source, bool) {
+	var ret v1alpha2.Source
+	var ok bool
+	if ret.IPBlocks, ok = intersectIPBlocks(a.IPBlocks, b.IPBlocks); !ok {
+		return ret, false
+	}
+	if ret.Namespaces, ok = IntersectGlobs(a.Namespaces, b.Namespaces); !ok {
+		return ret, false
+	}
+	if ret.Principals, ok = IntersectGlobs(a.Principals, b.Principals); !ok {
+		return ret, false
+	}
+	return ret, true
+}
+
+func intersectOperations(a, b *v1alpha2.Operation) (v1alpha2.Operation, bool) {
+	ret := v1alpha2.Operation{
+		NotHosts:   append(append([]string{}, a.NotHosts...), b.NotHosts...),
+		NotMethods: append(append([]string{}, a.NotMethods...), b.NotMethods...),
+		NotPaths:   append(append([]string{}, a.NotPaths...), b.NotPaths...),
+	}
+	var ok bool
+	if ret.Hosts, ok = IntersectGlobs(a.Hosts, b.Hosts); !ok {
+		return ret, false
+	}
+	if ret.Methods, ok = IntersectGlobs(a.Methods, b.Methods); !ok {
+		return ret, false
+	}
+	if ret.Paths, ok = IntersectGlobs(a.Paths, b.Paths); !ok {
+		return ret, false
+	}
+	return ret, true
+}
+
+// intersectIPBlocks returns the blocks in both block lists. Two CIDR blocks
+// either don't overlap or one contains the other.
+func intersectIPBlocks(a, b []string) ([]string, bool) {
+	if len(a) == 0 {
+		return b, true
+	}
+	if len(b) == 0 {
+		return a, true
+	}
+	var ret []string
+	for _, ba := range a {
+		na := parseIPBlock(ba)
+		for _, bb := range b {
+			nb := parseIPBlock(bb)
+			if na == nil || nb == nil {
+				continue
+			}
+			sa, _ := na.Mask.Size()
+			sb, _ := nb.Mask.Size()
+			switch {
+			case sa >= sb && nb.Contains(na.IP):
+				ret = append(ret, ba)
+			case sb > sa && na.Contains(nb.IP):
+				ret = append(ret, bb)
+			}
+		}
+	}
+	return ret, len(ret) > 0
+}
+
+func parseIPBlock(b string) *net.IPNet {
+	if !strings.Contains(b, "/") {
+		ip := net.ParseIP(b)
+		if ip == nil {
+			return nil
+		}
+		if ip.To4() != nil {
+			return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	}
+	_, n, err := net.ParseCIDR(b)
+	if err != nil {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httppolicy
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+)
+
+func TestCompose(t *testing.T) {
+	getPaths := func(paths ...string) v1alpha2.RuleSpec {
+		return v1alpha2.RuleSpec{Operations: []v1alpha2.Operation{{Methods: []string{"GET"}, Paths: paths}}}
+	}
+	fromNamespaces := func(ns ...string) v1alpha2.RuleSpec {
+		return v1alpha2.RuleSpec{From: []v1alpha2.Source{{Namespaces: ns}}}
+	}
+	denyAdmin := v1alpha2.RuleSpec{
+		Action:     v1alpha2.RuleActionDeny,
+		Operations: []v1alpha2.Operation{{Paths: []string{"/admin/*"}}},
+	}
+	auditPost := v1alpha2.RuleSpec{
+		Action:     v1alpha2.RuleActionAudit,
+		Operations: []v1alpha2.Operation{{Methods: []string{"POST"}}},
+	}
+	jwt := v1alpha2.JWTSpec{Issuer: "https://issuer", JwksURI: "https://issuer/jwks"}
+
+	tests := []struct {
+		name        string
+		composition v1alpha2.PolicyComposition
+		specs       []*v1alpha2.HTTPPolicySpec
+		want        *v1alpha2.HTTPPolicySpec
+		wantErr     bool
+	}{{
+		name:        "single policy",
+		composition: v1alpha2.PolicyCompositionAllOf,
+		specs:       []*v1alpha2.HTTPPolicySpec{{JWT: jwt, Rules: []v1alpha2.RuleSpec{getPaths("/x"), denyAdmin}}},
+		want:        &v1alpha2.HTTPPolicySpec{JWT: jwt, Rules: []v1alpha2.RuleSpec{getPaths("/x"), denyAdmin}},
+	}, {
+		name:        "any of",
+		composition: v1alpha2.PolicyCompositionAnyOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{Rules: []v1alpha2.RuleSpec{fromNamespaces("foo"), denyAdmin}},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{getPaths("/x"), fromNamespaces("foo"), denyAdmin}},
+	}, {
+		name:        "any of with a policy without ALLOW rules",
+		composition: v1alpha2.PolicyCompositionAnyOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{Rules: []v1alpha2.RuleSpec{denyAdmin}},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{{Action: v1alpha2.RuleActionAllow}, denyAdmin}},
+	}, {
+		name:        "any of with a policy without rules",
+		composition: v1alpha2.PolicyCompositionAnyOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+	}, {
+		name:        "all of",
+		composition: v1alpha2.PolicyCompositionAllOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x", "/y")}},
+			{Rules: []v1alpha2.RuleSpec{fromNamespaces("foo"), auditPost}},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{{
+			Action:     v1alpha2.RuleActionAllow,
+			From:       []v1alpha2.Source{{Namespaces: []string{"foo"}}},
+			Operations: []v1alpha2.Operation{{Methods: []string{"GET"}, Paths: []string{"/x", "/y"}}},
+		}, auditPost}},
+	}, {
+		name:        "all of intersects the operations",
+		composition: v1alpha2.PolicyCompositionAllOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x", "/y")}},
+			{Rules: []v1alpha2.RuleSpec{getPaths("/y", "/z")}},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{{
+			Action: v1alpha2.RuleActionAllow,
+			Operations: []v1alpha2.Operation{{
+				Methods:    []string{"GET"},
+				NotMethods: []string{},
+				Paths:      []string{"/y"},
+				NotPaths:   []string{},
+				NotHosts:   []string{},
+			}},
+		}}},
+	}, {
+		name:        "all of without common requests",
+		composition: v1alpha2.PolicyCompositionAllOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{Rules: []v1alpha2.RuleSpec{getPaths("/y")}},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{denyAllRule()}},
+	}, {
+		name:        "all of with a policy without rules",
+		composition: v1alpha2.PolicyCompositionAllOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x"), denyAdmin}},
+			{},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{denyAllRule(), denyAdmin}},
+	}, {
+		name:        "all of with a policy without ALLOW rules",
+		composition: v1alpha2.PolicyCompositionAllOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{Rules: []v1alpha2.RuleSpec{denyAdmin}},
+		},
+		want: &v1alpha2.HTTPPolicySpec{Rules: []v1alpha2.RuleSpec{getPaths("/x"), denyAdmin}},
+	}, {
+		name:        "same JWT",
+		composition: v1alpha2.PolicyCompositionAnyOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{JWT: jwt, Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{Rules: []v1alpha2.RuleSpec{getPaths("/y")}},
+			{JWT: jwt},
+		},
+		want: &v1alpha2.HTTPPolicySpec{JWT: jwt, Rules: []v1alpha2.RuleSpec{getPaths("/x"), getPaths("/y")}},
+	}, {
+		name:        "conflicting JWT",
+		composition: v1alpha2.PolicyCompositionAnyOf,
+		specs: []*v1alpha2.HTTPPolicySpec{
+			{JWT: jwt, Rules: []v1alpha2.RuleSpec{getPaths("/x")}},
+			{JWT: v1alpha2.JWTSpec{Issuer: "https://other", JwksURI: "https://other/jwks"}},
+		},
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Compose(tc.composition, tc.specs)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Compose() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Compose() (-want, +got) = %s", diff)
+			}
+		})
+	}
+}
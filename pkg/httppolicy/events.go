@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httppolicy
+
+import (
+	"github.com/cloudevents/sdk-go/pkg/cloudevents"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/events"
+)
+
+const (
+	// specVersionHeader carries the spec version of events in binary mode.
+	specVersionHeader = "Ce-Specversion"
+
+	// structuredContentTypes are the content types of structured and batched
+	// events, whose attributes aren't in the headers.
+	structuredContentTypes = "application/cloudevents*"
+)
+
+// eventSpecVersions are the CloudEvents spec versions EventPolicies match.
+var eventSpecVersions = []string{
+	cloudevents.CloudEventsVersionV1,
+	cloudevents.CloudEventsVersionV03,
+}
+
+// FromEventPolicy translates the EventPolicy into an HTTPPolicy matching the
+// headers of the events in binary mode. The headers depend on the spec
+// version, so each rule is translated for every version. A rule is left out
+// for the versions without one of its attributes. Structured and batched
+// events can't be matched by their headers, so policies with DENY rules deny
+// all of them.
+func FromEventPolicy(spec *v1alpha2.EventPolicySpec) *v1alpha2.HTTPPolicySpec {
+	ret := &v1alpha2.HTTPPolicySpec{}
+	deny := false
+	for _, r := range spec.Rules {
+		deny = deny || r.Action == v1alpha2.RuleActionDeny
+		for _, version := range eventSpecVersions {
+			rule, ok := eventRule(version, &r)
+			if ok {
+				ret.Rules = append(ret.Rules, rule)
+			}
+		}
+	}
+	if deny {
+		ret.Rules = append(ret.Rules, v1alpha2.RuleSpec{
+			Action:  v1alpha2.RuleActionDeny,
+			Headers: []v1alpha2.KeyValueMatch{{Key: "Content-Type", Values: []string{structuredContentTypes}}},
+		})
+	}
+	return ret
+}
+
+func eventRule(version string, r *v1alpha2.EventRule) (v1alpha2.RuleSpec, bool) {
+	rule := v1alpha2.RuleSpec{
+		Action:  r.Action,
+		Headers: []v1alpha2.KeyValueMatch{{Key: specVersionHeader, Values: []string{version}}},
+	}
+	for _, a := range r.Attributes {
+		h, ok := events.AttributeHeader(version, a.Name)
+		if !ok {
+			return rule, false
+		}
+		rule.Headers = append(rule.Headers, v1alpha2.KeyValueMatch{
+			Key:       h,
+			Values:    a.Values,
+			NotValues: a.NotValues,
+		})
+	}
+	return rule, true
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package httppolicy has helpers to work with HTTPPolicy specs independent of
+// the binding class.
+package httppolicy
+
+import "strings"
+
+// IntersectGlob returns the exact, prefix or suffix glob matching the values
+// both globs match. It returns false if there are no such values or the
+// intersection can't be expressed as a single glob.
+func IntersectGlob(a, b string) (string, bool) {
+	switch {
+	case a == "*":
+		return b, true
+	case b == "*":
+		return a, true
+	case !strings.Contains(a, "*"):
+		return a, MatchGlob(b, a)
+	case !strings.Contains(b, "*"):
+		return b, MatchGlob(a, b)
+	case strings.HasSuffix(a, "*") && strings.HasSuffix(b, "*"):
+		pa, pb := strings.TrimSuffix(a, "*"), strings.TrimSuffix(b, "*")
+		if strings.HasPrefix(pa, pb) {
+			return a, true
+		}
+		if strings.HasPrefix(pb, pa) {
+			return b, true
+		}
+	case strings.HasPrefix(a, "*") && strings.HasPrefix(b, "*"):
+		sa, sb := strings.TrimPrefix(a, "*"), strings.TrimPrefix(b, "*")
+		if strings.HasSuffix(sa, sb) {
+			return a, true
+		}
+		if strings.HasSuffix(sb, sa) {
+			return b, true
+		}
+	}
+	return "", false
+}
+
+// MatchGlob matches a value against an exact, prefix or suffix glob.
+func MatchGlob(glob, v string) bool {
+	switch {
+	case glob == "*":
+		return true
+	case strings.HasSuffix(glob, "*"):
+		return strings.HasPrefix(v, strings.TrimSuffix(glob, "*"))
+	case strings.HasPrefix(glob, "*"):
+		return strings.HasSuffix(v, strings.TrimPrefix(glob, "*"))
+	}
+	return glob == v
+}
+
+// IntersectGlobs returns the globs matching the values both glob lists match.
+// An empty list matches everything. Intersections that can't be expressed as
+// a single glob are left out, which makes the result stricter. It returns
+// false if no value can match both lists.
+func IntersectGlobs(a, b []string) ([]string, bool) {
+	if len(a) == 0 {
+		return b, true
+	}
+	if len(b) == 0 {
+		return a, true
+	}
+	var ret []string
+	seen := map[string]bool{}
+	for _, ga := range a {
+		for _, gb := range b {
+			if g, ok := IntersectGlob(ga, gb); ok && !seen[g] {
+				seen[g] = true
+				ret = append(ret, g)
+			}
+		}
+	}
+	return ret, len(ret) > 0
+}
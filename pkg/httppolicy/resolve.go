@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httppolicy
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/tracker"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	securitylisters "github.com/yolocs/knative-policy-binding/pkg/client/listers/security/v1alpha2"
+)
+
+const (
+	policyKind        = "HTTPPolicy"
+	clusterPolicyKind = "ClusterHTTPPolicy"
+	eventPolicyKind   = "EventPolicy"
+
+	// clusterScopeNamespace stands in for the namespace of ClusterHTTPPolicies
+	// in tracker references, since the tracker requires one. References
+	// include the kind, so it never matches an HTTPPolicy.
+	clusterScopeNamespace = "cluster-scoped"
+)
+
+// Resolve gets the HTTPPolicies, ClusterHTTPPolicies and EventPolicies
+// referenced by the binding and combines them into one spec. The binding is
+// tracked for changes to the policies. Namespace-wide bindings deny by
+// default.
+func Resolve(
+	lister securitylisters.HTTPPolicyLister,
+	clusterLister securitylisters.ClusterHTTPPolicyLister,
+	eventLister securitylisters.EventPolicyLister,
+	t tracker.Interface,
+	b *v1alpha2.HTTPPolicyBinding,
+) ([]kmeta.OwnerRefable, *v1alpha2.HTTPPolicySpec, error) {
+	return ResolveFor(lister, clusterLister, eventLister, t, b, b)
+}
+
+// ResolveFor is Resolve tracking the policies for owner, e.g. another binding
+// composing the policies of the binding.
+func ResolveFor(
+	lister securitylisters.HTTPPolicyLister,
+	clusterLister securitylisters.ClusterHTTPPolicyLister,
+	eventLister securitylisters.EventPolicyLister,
+	t tracker.Interface,
+	owner kmeta.Accessor,
+	b *v1alpha2.HTTPPolicyBinding,
+) ([]kmeta.OwnerRefable, *v1alpha2.HTTPPolicySpec, error) {
+	var policies []kmeta.OwnerRefable
+	var specs []*v1alpha2.HTTPPolicySpec
+	for _, ref := range b.Spec.PolicyRefs() {
+		// The references may leave out the API version.
+		ref.APIVersion = v1alpha2.SchemeGroupVersion.String()
+		if ref.Kind == "" {
+			ref.Kind = policyKind
+		}
+		trackRef := ref
+		if ref.Kind == clusterPolicyKind {
+			trackRef.Namespace = clusterScopeNamespace
+		}
+		// Track before getting the policy so the binding is reconciled again
+		// once a missing policy is created.
+		if err := t.Track(trackRef, owner); err != nil {
+			return nil, nil, fmt.Errorf("failed to track %s %s: %w", ref.Kind, refName(ref), err)
+		}
+
+		var err error
+		switch ref.Kind {
+		case clusterPolicyKind:
+			var p *v1alpha2.ClusterHTTPPolicy
+			if p, err = clusterLister.Get(ref.Name); err == nil {
+				policies = append(policies, p)
+				specs = append(specs, &p.Spec)
+			}
+		case policyKind:
+			var p *v1alpha2.HTTPPolicy
+			if p, err = lister.HTTPPolicies(ref.Namespace).Get(ref.Name); err == nil {
+				policies = append(policies, p)
+				specs = append(specs, &p.Spec)
+			}
+		case eventPolicyKind:
+			var p *v1alpha2.EventPolicy
+			if p, err = eventLister.EventPolicies(ref.Namespace).Get(ref.Name); err == nil {
+				policies = append(policies, p)
+				specs = append(specs, FromEventPolicy(&p.Spec))
+			}
+		default:
+			err = errors.New("unsupported policy kind")
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get %s %s: %w", ref.Kind, refName(ref), err)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, nil, errors.New("no policy is referenced")
+	}
+
+	spec, err := Compose(b.Spec.Composition, specs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.Spec.IsNamespaceWide() {
+		denyByDefault(spec)
+	}
+	return policies, spec, nil
+}
+
+func refName(ref corev1.ObjectReference) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+// OnChanged returns the informer callback notifying the tracker of changes to
+// HTTPPolicies, ClusterHTTPPolicies and EventPolicies. Informers leave out the type of the
+// objects, which the tracker needs to match them to the tracked references.
+func OnChanged(t tracker.Interface) func(interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		switch p := obj.(type) {
+		case *v1alpha2.HTTPPolicy:
+			cp := p.DeepCopy()
+			cp.SetGroupVersionKind(p.GetGroupVersionKind())
+			t.OnChanged(cp)
+		case *v1alpha2.ClusterHTTPPolicy:
+			cp := p.DeepCopy()
+			cp.SetGroupVersionKind(p.GetGroupVersionKind())
+			cp.Namespace = clusterScopeNamespace
+			t.OnChanged(cp)
+		case *v1alpha2.EventPolicy:
+			cp := p.DeepCopy()
+			cp.SetGroupVersionKind(p.GetGroupVersionKind())
+			t.OnChanged(cp)
+		}
+	}
+}
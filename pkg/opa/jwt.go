@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultJWTHeader = "Authorization"
+	bearerPrefix     = "Bearer "
+)
+
+// jwtDisabledRules is used when the policy doesn't verify JWT. No request
+// has a verified JWT, so rules matching principals or claims never match.
+const jwtDisabledRules = `default jwt_rejected = false
+
+jwt_payload = {} {
+  false
+}
+
+jwt_principal = concat("/", [jwt_payload.iss, jwt_payload.sub])
+
+jwt_claims[k] = vs {
+  vs := jwt_payload[k]
+  is_array(vs)
+}
+
+jwt_claims[k] = [v] {
+  v := jwt_payload[k]
+  is_string(v)
+}`
+
+// jwtVerifyRules verifies the JWT with the keys in "jwks". Like Istio, a
+// request without JWT is not rejected, it just has no principal or claims.
+// Builtin errors halt the evaluation, so tokens that are obviously not JWT
+// are rejected before being decoded.
+const jwtVerifyRules = `default jwt_rejected = false
+
+jwt_rejected {
+  jwt_triggered
+  jwt_token
+  not jwt_payload
+}
+
+jwt_well_formed {
+  re_match("^eyJ[A-Za-z0-9_-]*[.]eyJ[A-Za-z0-9_-]*[.][A-Za-z0-9_-]*$", jwt_token)
+}
+
+jwt_decoded = [header, payload] {
+  jwt_triggered
+  jwt_well_formed
+  [header, payload, _] := io.jwt.decode(jwt_token)
+}
+
+jwt_signature_valid {
+  jwt_decoded[0].alg == "RS256"
+  io.jwt.verify_rs256(jwt_token, jwks)
+}
+
+jwt_signature_valid {
+  jwt_decoded[0].alg == "PS256"
+  io.jwt.verify_ps256(jwt_token, jwks)
+}
+
+jwt_signature_valid {
+  jwt_decoded[0].alg == "ES256"
+  io.jwt.verify_es256(jwt_token, jwks)
+}
+
+jwt_expired {
+  time.now_ns() / 1000000000 >= jwt_decoded[1].exp
+}
+
+jwt_not_yet_valid {
+  time.now_ns() / 1000000000 < jwt_decoded[1].nbf
+}
+
+jwt_payload = jwt_decoded[1] {
+  jwt_signature_valid
+  jwt_issuer_valid
+  not jwt_expired
+  not jwt_not_yet_valid
+}
+
+jwt_principal = concat("/", [jwt_payload.iss, jwt_payload.sub])
+
+jwt_claims[k] = vs {
+  vs := jwt_payload[k]
+  is_array(vs)
+}
+
+jwt_claims[k] = [v] {
+  v := jwt_payload[k]
+  is_string(v)
+}`
+
+// JWT configures how the policy verifies JWT in requests.
+type JWT struct {
+	// Issuer of the JWT. If empty, any issuer is accepted.
+	Issuer string
+	// JWKS is the JSON Web Key Set to verify the JWT with.
+	JWKS string
+	// Header is the request header carrying the JWT after Prefix. Defaults
+	// to the "Authorization" header with the "Bearer " prefix.
+	Header string
+	Prefix string
+	// TriggerRules select the requests to verify the JWT for. If empty, all
+	// requests are verified.
+	TriggerRules []JWTTriggerRule
+}
+
+// JWTTriggerRule matches request paths. A path matches if it's not one of the
+// ExcludePaths and is one of the IncludePaths, if any.
+type JWTTriggerRule struct {
+	IncludePaths []string
+	ExcludePaths []string
+}
+
+func (j *JWT) String() string {
+	header, prefix := defaultJWTHeader, bearerPrefix
+	if j.Header != "" {
+		header, prefix = http.CanonicalHeaderKey(j.Header), j.Prefix
+	}
+	// JSON strings are valid rego strings.
+	jwks, _ := json.Marshal(j.JWKS)
+
+	var b strings.Builder
+	b.WriteString(jwtVerifyRules)
+	b.WriteString(fmt.Sprintf("\n\njwks = %s\n", jwks))
+	if j.Issuer == "" {
+		b.WriteString("\njwt_issuer_valid = true\n")
+	} else {
+		issuer, _ := json.Marshal(j.Issuer)
+		b.WriteString(fmt.Sprintf("\njwt_issuer_valid {\n  jwt_decoded[1].iss == %s\n}\n", issuer))
+	}
+	if prefix == "" {
+		b.WriteString(fmt.Sprintf("\njwt_token = input.httpRequest.header[%q][0]\n", header))
+	} else {
+		b.WriteString(fmt.Sprintf("\njwt_token = trim_prefix(input.httpRequest.header[%q][0], %q)\n", header, prefix))
+	}
+
+	if len(j.TriggerRules) == 0 {
+		b.WriteString("\njwt_triggered = true\n")
+		return b.String()
+	}
+	for i, tr := range j.TriggerRules {
+		triggered := newRuleBuilder("jwt_triggered")
+		if len(tr.ExcludePaths) > 0 {
+			excluded := newRuleBuilder(fmt.Sprintf("jwt_trigger_excluded[%d]", i))
+			excluded.AppendOneOf("input.httpRequest.path", tr.ExcludePaths)
+			b.WriteString("\n" + excluded.String() + "\n")
+			triggered.strs.WriteString(fmt.Sprintf("not jwt_trigger_excluded[%d]\n", i))
+		}
+		if len(tr.IncludePaths) > 0 {
+			triggered.AppendOneOf("input.httpRequest.path", tr.IncludePaths)
+		}
+		b.WriteString("\n" + triggered.String() + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// extendableRules are the rules of the policy package raw rules can define:
+// the sets of matched rules, and the decision before the DENY rules and the
+// JWT verification apply.
+var extendableRules = map[string]bool{
+	"matched":    true,
+	"denied":     true,
+	"audited":    true,
+	"allowed":    true,
+	"denied_any": true,
+}
+
+var (
+	reservedOnce  sync.Once
+	reservedRules map[string]bool
+)
+
+// reserved returns the other rules of the policy package, e.g. "allow" or the
+// JWT rules.
+func reserved() map[string]bool {
+	reservedOnce.Do(func() {
+		reservedRules = map[string]bool{}
+		pb := NewPolicyBuilder()
+		pb.SetJWT(&JWT{TriggerRules: []JWTTriggerRule{{IncludePaths: []string{"*"}, ExcludePaths: []string{"*"}}}})
+		for _, m := range []string{pb.String(), NewPolicyBuilder().String()} {
+			parsed, err := ast.ParseModule("policy", m)
+			if err != nil {
+				panic(err)
+			}
+			for _, r := range parsed.Rules {
+				if name := r.Head.Name.String(); !extendableRules[name] {
+					reservedRules[name] = true
+				}
+			}
+		}
+	})
+	return reservedRules
+}
+
+// SetRego merges raw rules into the policy package. They can add to the
+// "matched", "denied" and "audited" sets like the rules of the builder, or
+// define "allowed" and "denied_any" directly. Rules named "allow", like the
+// ones of v1alpha1 policies, are renamed "allowed", and their "false" default
+// is left to the package.
+// The other rules of the package can't be defined, so that the DENY rules and
+// the JWT verification always apply.
+func (pb *PolicyBuilder) SetRego(rego string) error {
+	m, err := ast.ParseModule("rego", "package security.knative.dev\n\n"+rego)
+	if err != nil {
+		return err
+	}
+	renamed, err := renameAllow(m)
+	if err != nil {
+		return err
+	}
+	heads := map[string]bool{}
+	var rules []*ast.Rule
+	for _, r := range m.Rules {
+		name := r.Head.Name.String()
+		if reserved()[name] {
+			return fmt.Errorf("rule %q is reserved", name)
+		}
+		if r.Default && name == "allowed" {
+			if !r.Head.Value.Equal(ast.BooleanTerm(false)) {
+				return fmt.Errorf("rules %q and %q can only default to false", "allow", name)
+			}
+			renamed = true
+			continue
+		}
+		heads[name] = true
+		rules = append(rules, r)
+	}
+	if renamed {
+		rego = regoString(m.Imports, rules)
+	}
+	pb.rego, pb.regoHeads = rego, heads
+	return nil
+}
+
+// renameAllow renames the "allow" rules and the references to them
+// "allowed". It returns whether there were any.
+func renameAllow(m *ast.Module) (bool, error) {
+	renamed := false
+	for i, r := range m.Rules {
+		x, err := ast.TransformVars(r, func(v ast.Var) (ast.Value, error) {
+			if v.Equal(ast.Var("allow")) {
+				renamed = true
+				return ast.Var("allowed"), nil
+			}
+			return v, nil
+		})
+		if err != nil {
+			return false, err
+		}
+		m.Rules[i] = x.(*ast.Rule)
+	}
+	return renamed, nil
+}
+
+// regoString prints the imports and rules of a module without its package.
+func regoString(imports []*ast.Import, rules []*ast.Rule) string {
+	var lines []string
+	for _, imp := range imports {
+		lines = append(lines, imp.String())
+	}
+	for _, r := range rules {
+		lines = append(lines, r.String())
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// CheckRego checks that the raw rules compile in the policy package, with or
+// without rules of each action and JWT verification.
+func CheckRego(rego string) error {
+	for _, jwt := range []*JWT{nil, {}} {
+		for _, withRules := range []bool{false, true} {
+			pb := NewPolicyBuilder()
+			if jwt != nil {
+				pb.SetJWT(jwt)
+			}
+			if withRules {
+				for a := range actionSets {
+					pb.NewActionRule("check", a)
+				}
+			}
+			if err := pb.SetRego(rego); err != nil {
+				return err
+			}
+			if _, err := ast.CompileModules(map[string]string{"policy": pb.String()}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
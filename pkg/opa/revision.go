@@ -0,0 +1,29 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Revision returns the revision of a rego module, which is derived from its
+// content. Both the controller and the agent use it to identify a policy.
+func Revision(module string) string {
+	sum := sha256.Sum256([]byte(module))
+	return hex.EncodeToString(sum[:8])
+}
@@ -24,22 +24,107 @@ import (
 )
 
 func init() {
-	rt, err := template.New("policy").Parse("package security.knative.dev\n\ndefault allow = false\n\n{{.CustomRules}}")
+	rt, err := template.New("policy").Parse(policyTemplate)
 	if err != nil {
 		panic(err)
 	}
 	regoTemplate = rt
+
+	rt, err = template.New("raw").Parse(rawTemplate)
+	if err != nil {
+		panic(err)
+	}
+	rawRegoTemplate = rt
+}
+
+const policyTemplate = `package security.knative.dev
+
+default allow = false
+
+allow {
+  allowed
+  not denied_any
+  not jwt_rejected
+}
+
+reason = "JWTVerificationFailed" {
+  jwt_rejected
+} else = "DeniedByRule" {
+  denied_any
+}
+
+status_code = 401 {
+  jwt_rejected
 }
 
-var regoTemplate *template.Template
+{{.DecisionRules}}
+
+{{.JWTRules}}
+
+` + sourceRules + `
+
+{{.CustomRules}}`
+
+// sourceRules extract the peer of the request. The IP comes from the remote
+// address, the principal and namespace come from the SPIFFE identity of the
+// peer, e.g. "spiffe://cluster.local/ns/default/sa/default". The identity is
+// only set by Envoy's ext_authz requests, the principal and namespace are
+// undefined otherwise.
+const sourceRules = `source_ip = ip {
+  parts := split(input.httpRequest.remoteAddr, ":")
+  count(parts) == 2
+  ip := parts[0]
+}
+
+source_ip = ip {
+  startswith(input.httpRequest.remoteAddr, "[")
+  end := indexof(input.httpRequest.remoteAddr, "]")
+  ip := substring(input.httpRequest.remoteAddr, 1, end - 1)
+}
+
+source_principal = p {
+  p := trim_prefix(input.source.identity, "spiffe://")
+  p != ""
+}
+
+source_namespace = ns {
+  parts := split(source_principal, "/")
+  parts[1] == "ns"
+  ns := parts[2]
+}`
+
+// rawTemplate leaves the decision to the allow rules of raw policies. It
+// doesn't define any other rule, so that raw policies can use any name.
+const rawTemplate = `package security.knative.dev
+
+default allow = false
+
+{{.CustomRules}}`
+
+var (
+	regoTemplate    *template.Template
+	rawRegoTemplate *template.Template
+)
 
 type PolicyTemplate struct {
-	CustomRules string
+	CustomRules   string
+	DecisionRules string
+	JWTRules      string
 }
 
+// GenerateFromTemplate wraps the raw rules of v1alpha1 policies into the
+// policy package.
 func GenerateFromTemplate(rules string) string {
+	return execute(rawRegoTemplate, &PolicyTemplate{CustomRules: rules})
+}
+
+func generate(pt *PolicyTemplate) string {
+	return execute(regoTemplate, pt)
+}
+
+func execute(t *template.Template, pt *PolicyTemplate) string {
 	buf := bytes.NewBuffer([]byte{})
-	if err := regoTemplate.Execute(buf, &PolicyTemplate{CustomRules: rules}); err != nil {
+	if err := t.Execute(buf, pt); err != nil {
 		panic(err)
 	}
 	return buf.String()
@@ -47,61 +132,248 @@ func GenerateFromTemplate(rules string) string {
 
 type PolicyBuilder struct {
 	rules []*RuleBuilder
+	jwt   *JWT
+
+	// Raw rules and the names of the rules they define.
+	rego      string
+	regoHeads map[string]bool
 }
 
 func NewPolicyBuilder() *PolicyBuilder {
 	return &PolicyBuilder{rules: []*RuleBuilder{}}
 }
 
-func (pb *PolicyBuilder) NewRule() *RuleBuilder {
-	ret := &RuleBuilder{strs: &strings.Builder{}}
+// Action is the action to take when a rule matches.
+type Action string
+
+const (
+	ActionAllow Action = "ALLOW"
+	ActionDeny  Action = "DENY"
+	ActionAudit Action = "AUDIT"
+)
+
+// The sets of matched rule names for each action.
+var actionSets = map[Action]string{
+	ActionAllow: "matched",
+	ActionDeny:  "denied",
+	ActionAudit: "audited",
+}
+
+// NewRule adds an ALLOW rule to the policy. The name is reported back in the
+// decision response when the rule matches.
+func (pb *PolicyBuilder) NewRule(name string) *RuleBuilder {
+	return pb.NewActionRule(name, ActionAllow)
+}
+
+// NewActionRule adds a rule with the action to the policy. Requests matching
+// any DENY rule are denied. Otherwise requests matching any ALLOW rule are
+// allowed, and if there are no ALLOW rules, all requests are allowed.
+// AUDIT rules don't affect the decision.
+func (pb *PolicyBuilder) NewActionRule(name string, action Action) *RuleBuilder {
+	set, ok := actionSets[action]
+	if !ok {
+		set = actionSets[ActionAllow]
+	}
+	ret := newRuleBuilder(fmt.Sprintf("%s[%q]", set, name))
+	ret.action = action
 	pb.rules = append(pb.rules, ret)
 	return ret
 }
 
+// SetJWT makes the policy verify the JWT in requests. Rules can only match
+// principals and claims of verified JWTs.
+func (pb *PolicyBuilder) SetJWT(jwt *JWT) {
+	pb.jwt = jwt
+}
+
 func (pb *PolicyBuilder) String() string {
 	var rules []string
 	for _, r := range pb.rules {
 		rules = append(rules, r.String())
 	}
+	if pb.rego != "" {
+		rules = append(rules, pb.rego)
+	}
 	combined := strings.Join(rules, "\n")
-	return GenerateFromTemplate(combined)
+	jwtRules := jwtDisabledRules
+	if pb.jwt != nil {
+		jwtRules = pb.jwt.String()
+	}
+	return generate(&PolicyTemplate{CustomRules: combined, DecisionRules: pb.decisionRules(), JWTRules: jwtRules})
+}
+
+// decisionRules only refers to the rule sets the policy has, including the
+// ones raw rules add to. Requests are allowed by default if there are only
+// DENY or AUDIT rules, unless raw rules define "allowed".
+func (pb *PolicyBuilder) decisionRules() string {
+	actions := map[Action]bool{}
+	for _, r := range pb.rules {
+		actions[r.action] = true
+	}
+	for a, set := range actionSets {
+		if pb.regoHeads[set] {
+			actions[a] = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("default allowed = false\n")
+	switch {
+	case actions[ActionAllow]:
+		b.WriteString("\nallowed {\n  matched[_]\n}\n")
+	case pb.regoHeads["allowed"]:
+		// Raw rules defining "allowed" decide on their own.
+	case len(actions) > 0:
+		b.WriteString("\nallowed = true\n")
+	}
+	b.WriteString("\ndefault denied_any = false\n")
+	if actions[ActionDeny] {
+		b.WriteString("\ndenied_any {\n  denied[_]\n}\n")
+	}
+	return b.String()
 }
 
 type RuleBuilder struct {
-	index int
-	strs  *strings.Builder
+	head   string
+	action Action
+	index  int
+	strs   *strings.Builder
 }
 
+func newRuleBuilder(head string) *RuleBuilder {
+	return &RuleBuilder{head: head, strs: &strings.Builder{}}
+}
+
+// AppendOneOf requires any of the values at path to match any of the allowed
+// values.
 func (rb *RuleBuilder) AppendOneOf(path string, allowed []string) {
-	prefix := []string{}
-	suffix := []string{}
-	reg := []string{}
-	for _, v := range allowed {
+	rb.appendOneOf(path, allowed)
+}
+
+// appendOneOf is AppendOneOf, also satisfied by any of the extra sets being
+// non-empty.
+func (rb *RuleBuilder) appendOneOf(path string, allowed []string, extra ...string) {
+	if len(allowed) == 0 {
+		return
+	}
+	prefix, suffix, exact := splitGlobs(allowed)
+	// The expressions of a rule body are ANDed, so the matches of each kind
+	// are collected into sets to OR them.
+	matches := extra
+	if len(prefix) > 0 {
+		rb.strs.WriteString(fmt.Sprintf("pre%d := [%s]\n", rb.index, strings.Join(prefix, ",")))
+		matches = append(matches, fmt.Sprintf("{v | v := %s; startswith(v, pre%d[_])}", path, rb.index))
+	}
+	if len(suffix) > 0 {
+		rb.strs.WriteString(fmt.Sprintf("suff%d := [%s]\n", rb.index, strings.Join(suffix, ",")))
+		matches = append(matches, fmt.Sprintf("{v | v := %s; endswith(v, suff%d[_])}", path, rb.index))
+	}
+	if len(exact) > 0 {
+		rb.strs.WriteString(fmt.Sprintf("exact%d := [%s]\n", rb.index, strings.Join(exact, ",")))
+		matches = append(matches, fmt.Sprintf("{v | v := %s; v == exact%d[_]}", path, rb.index))
+	}
+	rb.strs.WriteString(fmt.Sprintf("count(%s) > 0\n", strings.Join(matches, " | ")))
+	rb.index++
+}
+
+// AppendNoneOf requires none of the values at path to match the disallowed
+// values. It also holds if there is no value at path.
+func (rb *RuleBuilder) AppendNoneOf(path string, disallowed []string) {
+	// Negation can't iterate, so count the matching values instead.
+	prefix, suffix, exact := splitGlobs(disallowed)
+	if len(prefix) > 0 {
+		rb.strs.WriteString(fmt.Sprintf("notpre%d := [%s]\n", rb.index, strings.Join(prefix, ",")))
+		rb.strs.WriteString(fmt.Sprintf("count([v | v := %s; startswith(v, notpre%d[_])]) == 0\n", path, rb.index))
+	}
+	if len(suffix) > 0 {
+		rb.strs.WriteString(fmt.Sprintf("notsuff%d := [%s]\n", rb.index, strings.Join(suffix, ",")))
+		rb.strs.WriteString(fmt.Sprintf("count([v | v := %s; endswith(v, notsuff%d[_])]) == 0\n", path, rb.index))
+	}
+	if len(exact) > 0 {
+		rb.strs.WriteString(fmt.Sprintf("notexact%d := [%s]\n", rb.index, strings.Join(exact, ",")))
+		rb.strs.WriteString(fmt.Sprintf("count([v | v := %s; v == notexact%d[_]]) == 0\n", path, rb.index))
+	}
+	rb.index++
+}
+
+// splitGlobs splits the values into quoted prefixes, suffixes and exact
+// values. "*" is the empty prefix.
+func splitGlobs(values []string) (prefix, suffix, exact []string) {
+	for _, v := range values {
 		if strings.HasSuffix(v, "*") {
 			prefix = append(prefix, fmt.Sprintf("%q", strings.TrimSuffix(v, "*")))
 		} else if strings.HasPrefix(v, "*") {
 			suffix = append(suffix, fmt.Sprintf("%q", strings.TrimPrefix(v, "*")))
 		} else {
-			reg = append(reg, fmt.Sprintf("%q", v))
+			exact = append(exact, fmt.Sprintf("%q", v))
 		}
 	}
+	return prefix, suffix, exact
+}
 
-	if len(prefix) > 0 {
-		rb.strs.WriteString(fmt.Sprintf("pre%d := [%s]\n", rb.index, strings.Join(prefix, ",")))
-		rb.strs.WriteString(fmt.Sprintf("startswith(%s, pre%d[_])\n", path, rb.index))
+// AppendPrincipals requires the principal ("iss/sub") of the verified JWT to
+// be one of the allowed values.
+func (rb *RuleBuilder) AppendPrincipals(allowed []string) {
+	rb.AppendOneOf("jwt_principal", allowed)
+}
+
+// AppendClaim requires the claim of the verified JWT to have one of the
+// allowed values and none of the disallowed values. For list claims, any of
+// the values is enough to match.
+func (rb *RuleBuilder) AppendClaim(key string, allowed, disallowed []string) {
+	rb.AppendOneOf(fmt.Sprintf("jwt_claims[%q][_]", key), allowed)
+	rb.AppendNoneOf(fmt.Sprintf("jwt_claims[%q][_]", key), disallowed)
+}
+
+// AppendSourcePrincipals requires the identity ("<trust domain>/ns/<ns>/sa/<sa>")
+// of the peer to be one of the allowed values.
+func (rb *RuleBuilder) AppendSourcePrincipals(allowed []string) {
+	rb.appendSourceOneOf("source_principal", allowed)
+}
+
+// AppendSourceNamespaces requires the namespace of the peer identity to be
+// one of the allowed values.
+func (rb *RuleBuilder) AppendSourceNamespaces(allowed []string) {
+	rb.appendSourceOneOf("source_namespace", allowed)
+}
+
+// appendSourceOneOf matches the peer identity. Only Envoy's ext_authz requests
+// have one, so DENY rules also match requests without it, which are denied
+// instead of bypassing the rule.
+func (rb *RuleBuilder) appendSourceOneOf(ref string, allowed []string) {
+	if rb.action == ActionDeny {
+		rb.appendOneOf(ref, allowed, fmt.Sprintf("{true | not %s}", ref))
+		return
 	}
-	if len(suffix) > 0 {
-		rb.strs.WriteString(fmt.Sprintf("suff%d := [%s]\n", rb.index, strings.Join(suffix, ",")))
-		rb.strs.WriteString(fmt.Sprintf("endswith(%s, suff%d[_])\n", path, rb.index))
+	rb.appendOneOf(ref, allowed)
+}
+
+// AppendIPBlocks requires the peer IP to be in one of the CIDR blocks. Bare
+// IPs are treated as single address blocks.
+func (rb *RuleBuilder) AppendIPBlocks(blocks []string) {
+	if len(blocks) == 0 {
+		return
 	}
-	if len(reg) > 0 {
-		rb.strs.WriteString(fmt.Sprintf("reg%d := [%s]\n", rb.index, strings.Join(reg, ",")))
-		rb.strs.WriteString(fmt.Sprintf("re_match(reg%d[_], %s)\n", rb.index, path))
+	var cidrs []string
+	for _, b := range blocks {
+		if !strings.Contains(b, "/") {
+			if strings.Contains(b, ":") {
+				b += "/128"
+			} else {
+				b += "/32"
+			}
+		}
+		cidrs = append(cidrs, fmt.Sprintf("%q", b))
 	}
+	rb.strs.WriteString(fmt.Sprintf("cidrs%d := [%s]\n", rb.index, strings.Join(cidrs, ",")))
+	rb.strs.WriteString(fmt.Sprintf("net.cidr_contains(cidrs%d[_], source_ip)\n", rb.index))
 	rb.index++
 }
 
 func (rb *RuleBuilder) String() string {
-	return fmt.Sprintf("allow {\n%s}", rb.strs.String())
+	if rb.strs.Len() == 0 {
+		// A rule without conditions matches everything.
+		return fmt.Sprintf("%s {\ntrue\n}", rb.head)
+	}
+	return fmt.Sprintf("%s {\n%s}", rb.head, rb.strs.String())
 }
@@ -0,0 +1,310 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+type request struct {
+	method     string
+	path       string
+	header     map[string][]string
+	remoteAddr string
+	identity   string
+}
+
+func (r request) input() map[string]interface{} {
+	header := map[string]interface{}{}
+	for k, vs := range r.header {
+		var values []interface{}
+		for _, v := range vs {
+			values = append(values, v)
+		}
+		header[k] = values
+	}
+	in := map[string]interface{}{
+		"httpRequest": map[string]interface{}{
+			"method":     r.method,
+			"path":       r.path,
+			"header":     header,
+			"remoteAddr": r.remoteAddr,
+		},
+	}
+	if r.identity != "" {
+		in["source"] = map[string]interface{}{"identity": r.identity}
+	}
+	return in
+}
+
+// evalAllow evaluates the "allow" decision of the policy for the request.
+func evalAllow(t *testing.T, policy string, req request) bool {
+	t.Helper()
+	rs, err := rego.New(
+		rego.Query("data.security.knative.dev.allow"),
+		rego.Module("policy.rego", policy),
+		rego.Input(req.input()),
+	).Eval(context.Background())
+	if err != nil {
+		t.Fatalf("Eval() = %v\n%s", err, policy)
+	}
+	if len(rs) != 1 || len(rs[0].Expressions) != 1 {
+		t.Fatalf("Eval() = %v, want one result\n%s", rs, policy)
+	}
+	allow, ok := rs[0].Expressions[0].Value.(bool)
+	if !ok {
+		t.Fatalf("allow = %v, want a boolean", rs[0].Expressions[0].Value)
+	}
+	return allow
+}
+
+func TestPolicyBuilder(t *testing.T) {
+	get := request{method: "GET", path: "/x"}
+	post := request{method: "POST", path: "/x"}
+	admin := request{method: "GET", path: "/admin/users"}
+
+	tests := []struct {
+		name  string
+		build func(*PolicyBuilder)
+		want  map[string]bool
+		reqs  map[string]request
+	}{{
+		name:  "no rules",
+		build: func(*PolicyBuilder) {},
+		reqs:  map[string]request{"get": get},
+		want:  map[string]bool{"get": false},
+	}, {
+		name: "allow rule",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("get").AppendOneOf("input.httpRequest.method", []string{"GET"})
+		},
+		reqs: map[string]request{"get": get, "post": post},
+		want: map[string]bool{"get": true, "post": false},
+	}, {
+		name: "mixed prefix, suffix and exact values",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("paths").AppendOneOf("input.httpRequest.path", []string{"/admin/*", "*.json", "/x"})
+		},
+		reqs: map[string]request{
+			"prefix": admin,
+			"suffix": {method: "GET", path: "/data.json"},
+			"exact":  get,
+			"other":  {method: "GET", path: "/y"},
+		},
+		want: map[string]bool{"prefix": true, "suffix": true, "exact": true, "other": false},
+	}, {
+		name: "none of",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("not-admin").AppendNoneOf("input.httpRequest.path", []string{"/admin/*"})
+		},
+		reqs: map[string]request{"get": get, "admin": admin},
+		want: map[string]bool{"get": true, "admin": false},
+	}, {
+		name: "deny rule only",
+		build: func(pb *PolicyBuilder) {
+			pb.NewActionRule("admin", ActionDeny).AppendOneOf("input.httpRequest.path", []string{"/admin/*"})
+		},
+		reqs: map[string]request{"get": get, "admin": admin},
+		want: map[string]bool{"get": true, "admin": false},
+	}, {
+		name: "deny rule overrides allow rule",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("all")
+			pb.NewActionRule("admin", ActionDeny).AppendOneOf("input.httpRequest.path", []string{"/admin/*"})
+		},
+		reqs: map[string]request{"get": get, "admin": admin},
+		want: map[string]bool{"get": true, "admin": false},
+	}, {
+		name: "audit rule only",
+		build: func(pb *PolicyBuilder) {
+			pb.NewActionRule("audit", ActionAudit).AppendOneOf("input.httpRequest.method", []string{"POST"})
+		},
+		reqs: map[string]request{"get": get, "post": post},
+		want: map[string]bool{"get": true, "post": true},
+	}, {
+		name: "source namespaces",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("ns").AppendSourceNamespaces([]string{"foo"})
+		},
+		reqs: map[string]request{
+			"foo":         {method: "GET", path: "/", identity: "spiffe://cluster.local/ns/foo/sa/default"},
+			"bar":         {method: "GET", path: "/", identity: "spiffe://cluster.local/ns/bar/sa/default"},
+			"no identity": get,
+		},
+		want: map[string]bool{"foo": true, "bar": false, "no identity": false},
+	}, {
+		name: "deny source namespaces",
+		build: func(pb *PolicyBuilder) {
+			pb.NewActionRule("ns", ActionDeny).AppendSourceNamespaces([]string{"foo"})
+		},
+		reqs: map[string]request{
+			"foo":         {method: "GET", path: "/", identity: "spiffe://cluster.local/ns/foo/sa/default"},
+			"bar":         {method: "GET", path: "/", identity: "spiffe://cluster.local/ns/bar/sa/default"},
+			"no identity": get,
+		},
+		want: map[string]bool{"foo": false, "bar": true, "no identity": false},
+	}, {
+		name: "ip blocks",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("ips").AppendIPBlocks([]string{"10.0.0.0/8", "::1"})
+		},
+		reqs: map[string]request{
+			"in block": {method: "GET", path: "/", remoteAddr: "10.1.2.3:4567"},
+			"ipv6":     {method: "GET", path: "/", remoteAddr: "[::1]:4567"},
+			"outside":  {method: "GET", path: "/", remoteAddr: "192.168.0.1:4567"},
+		},
+		want: map[string]bool{"in block": true, "ipv6": true, "outside": false},
+	}, {
+		name: "header",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("header").AppendOneOf(`input.httpRequest.header["X-Foo"][_]`, []string{"bar"})
+		},
+		reqs: map[string]request{
+			"match":     {method: "GET", path: "/", header: map[string][]string{"X-Foo": {"baz", "bar"}}},
+			"mismatch":  {method: "GET", path: "/", header: map[string][]string{"X-Foo": {"baz"}}},
+			"no header": get,
+		},
+		want: map[string]bool{"match": true, "mismatch": false, "no header": false},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pb := NewPolicyBuilder()
+			tc.build(pb)
+			policy := pb.String()
+			for name, req := range tc.reqs {
+				if got := evalAllow(t, policy, req); got != tc.want[name] {
+					t.Errorf("allow(%s) = %v, want %v\n%s", name, got, tc.want[name], policy)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyBuilderRego(t *testing.T) {
+	get := request{method: "GET", path: "/x"}
+	post := request{method: "POST", path: "/x"}
+	admin := request{method: "GET", path: "/admin/users"}
+
+	const allowGet = `allow {
+  input.httpRequest.method == "GET"
+}`
+
+	tests := []struct {
+		name  string
+		build func(*PolicyBuilder)
+		rego  string
+		reqs  map[string]request
+		want  map[string]bool
+	}{{
+		name: "raw allow",
+		rego: allowGet,
+		reqs: map[string]request{"get": get, "post": post},
+		want: map[string]bool{"get": true, "post": false},
+	}, {
+		name: "raw allow with a default",
+		rego: "default allow = false\n\n" + allowGet,
+		reqs: map[string]request{"get": get, "post": post},
+		want: map[string]bool{"get": true, "post": false},
+	}, {
+		name: "raw allow with deny rule",
+		build: func(pb *PolicyBuilder) {
+			pb.NewActionRule("admin", ActionDeny).AppendOneOf("input.httpRequest.path", []string{"/admin/*"})
+		},
+		rego: allowGet,
+		reqs: map[string]request{"get": get, "post": post, "admin": admin},
+		want: map[string]bool{"get": true, "post": false, "admin": false},
+	}, {
+		name: "raw allow with audit rule",
+		build: func(pb *PolicyBuilder) {
+			pb.NewActionRule("audit", ActionAudit).AppendOneOf("input.httpRequest.method", []string{"POST"})
+		},
+		rego: allowGet,
+		reqs: map[string]request{"get": get, "post": post},
+		want: map[string]bool{"get": true, "post": false},
+	}, {
+		name: "raw allowed with allow rule",
+		build: func(pb *PolicyBuilder) {
+			pb.NewRule("admin").AppendOneOf("input.httpRequest.path", []string{"/admin/*"})
+		},
+		rego: allowGet,
+		reqs: map[string]request{"get": get, "post": post, "admin": admin},
+		want: map[string]bool{"get": true, "post": false, "admin": true},
+	}, {
+		name: "raw matched",
+		rego: `matched["post"] {
+  input.httpRequest.method == "POST"
+}`,
+		reqs: map[string]request{"get": get, "post": post},
+		want: map[string]bool{"get": false, "post": true},
+	}, {
+		name: "raw denied",
+		rego: `denied["admin"] {
+  startswith(input.httpRequest.path, "/admin/")
+}`,
+		reqs: map[string]request{"get": get, "admin": admin},
+		want: map[string]bool{"get": true, "admin": false},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckRego(tc.rego); err != nil {
+				t.Fatalf("CheckRego() = %v", err)
+			}
+			pb := NewPolicyBuilder()
+			if tc.build != nil {
+				tc.build(pb)
+			}
+			if err := pb.SetRego(tc.rego); err != nil {
+				t.Fatalf("SetRego() = %v", err)
+			}
+			policy := pb.String()
+			for name, req := range tc.reqs {
+				if got := evalAllow(t, policy, req); got != tc.want[name] {
+					t.Errorf("allow(%s) = %v, want %v\n%s", name, got, tc.want[name], policy)
+				}
+			}
+		})
+	}
+}
+
+func TestSetRegoErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rego string
+	}{{
+		name: "reserved rule",
+		rego: "jwt_rejected = true",
+	}, {
+		name: "allow default true",
+		rego: "default allow = true",
+	}, {
+		name: "syntax error",
+		rego: "allow {",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := NewPolicyBuilder().SetRego(tc.rego); err == nil {
+				t.Error("SetRego() = nil, want error")
+			}
+		})
+	}
+}
@@ -17,117 +17,184 @@ limitations under the License.
 package eventpolicy
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
 	"github.com/cloudevents/sdk-go/pkg/cloudevents"
-	cehttp "github.com/cloudevents/sdk-go/pkg/cloudevents/transport/http"
 	security "github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha1"
+	"github.com/yolocs/knative-policy-binding/pkg/events"
 )
 
-var (
-	codecV03 *cehttp.CodecV03 = &cehttp.CodecV03{}
-	codecV1  *cehttp.CodecV1  = &cehttp.CodecV1{}
-)
+// structuredAttributes map the rule names to the members of structured events
+// for each spec version. Extensions are members named after them.
+var structuredAttributes = map[string]map[string]string{
+	cloudevents.CloudEventsVersionV1: {
+		"id":          "id",
+		"type":        "type",
+		"source":      "source",
+		"subject":     "subject",
+		"dataschema":  "dataschema",
+		"contenttype": "datacontenttype",
+	},
+	cloudevents.CloudEventsVersionV03: {
+		"id":          "id",
+		"type":        "type",
+		"source":      "source",
+		"subject":     "subject",
+		"dataschema":  "schemaurl",
+		"contenttype": "datacontenttype",
+		"encoding":    "datacontentencoding",
+	},
+}
 
-func coreSetters(e *cloudevents.Event) map[string]func(string) {
-	return map[string]func(string){
-		"id":          e.SetID,
-		"type":        e.SetType,
-		"source":      e.SetSource,
-		"subject":     e.SetSubject,
-		"dataschema":  e.SetDataSchema,
-		"contenttype": e.SetDataContentType,
-		"encoding":    e.SetDataContentEncoding,
-	}
+// structuredRules allow structured and batched events whose attributes in the
+// body match a rule group. A batch is allowed only if every event in it is.
+var structuredRules = fmt.Sprintf(`allow {
+  startswith(input.httpRequest.header["Content-Type"][_], %q)
+  ce_structured_event(input.httpRequest.body)
+}
+
+allow {
+  startswith(input.httpRequest.header["Content-Type"][_], %q)
+  count(input.httpRequest.body) > 0
+  not ce_batch_denied
 }
 
-func MakeOpenPolicyRule(eventRules [][]security.EventPolicyRule) string {
+ce_batch_denied {
+  e := input.httpRequest.body[_]
+  not ce_structured_event(e)
+}
+`, cloudevents.ApplicationCloudEventsJSON, cloudevents.ApplicationCloudEventsBatchJSON)
+
+// structuredModeRule tells requests carrying structured or batched events,
+// whose headers aren't the event attributes, from events in binary mode.
+var structuredModeRule = fmt.Sprintf(`ce_structured_mode {
+  startswith(lower(input.httpRequest.header["Content-Type"][_]), %q)
+}
+`, "application/cloudevents")
+
+// MakeOpenPolicyRule compiles the event rules into Rego. Events in binary mode
+// are matched by their headers, as long as the content type isn't one of
+// cloudevents. If checkPayload is true, events in structured or batched mode
+// are matched by their body as well, otherwise they're denied.
+func MakeOpenPolicyRule(eventRules [][]security.EventPolicyRule, checkPayload bool) string {
 	rgs := []string{}
 	for _, eg := range eventRules {
-		rgs = append(rgs, makeRuleGroup(eg))
+		rgs = append(rgs, makeRuleGroup(eg, checkPayload))
+	}
+	if len(eventRules) > 0 {
+		rgs = append(rgs, structuredModeRule)
+	}
+	if checkPayload && len(eventRules) > 0 {
+		rgs = append(rgs, structuredRules)
 	}
 	return strings.Join(rgs, "\n")
 }
 
-func makeRuleGroup(rules []security.EventPolicyRule) string {
+func makeRuleGroup(rules []security.EventPolicyRule, checkPayload bool) string {
 	v1Ev := &cloudevents.Event{}
 	v1Ev.SetSpecVersion(cloudevents.CloudEventsVersionV1)
-	v1Setters := coreSetters(v1Ev)
 
 	v03Ev := &cloudevents.Event{}
 	v03Ev.SetSpecVersion(cloudevents.CloudEventsVersionV03)
-	v03Setters := coreSetters(v03Ev)
 
-	m := make(map[string]security.EventPolicyRule)
+	for _, r := range rules {
+		events.SetAttribute(v1Ev, r.Name, r.Name)
+		events.SetAttribute(v03Ev, r.Name, r.Name)
+	}
+
+	groups := []string{
+		wrapRule(binaryMatches(*v1Ev, rules)),
+		wrapRule(binaryMatches(*v03Ev, rules)),
+	}
+	if checkPayload {
+		groups = append(groups,
+			wrapStructuredRule(structuredMatches(cloudevents.CloudEventsVersionV1, rules)),
+			wrapStructuredRule(structuredMatches(cloudevents.CloudEventsVersionV03, rules)),
+		)
+	}
+	return strings.Join(groups, "\n")
+}
 
+// binaryMatches matches the headers of the event encoded in binary mode. The
+// attributes of the encoded event are set to the names of the rules, so that
+// the headers carrying them can be found. The data is the body. Requests with
+// a cloudevents content type aren't in binary mode, whatever their headers.
+func binaryMatches(e cloudevents.Event, rules []security.EventPolicyRule) string {
+	b := &strings.Builder{}
+	header, err := events.EncodeHeader(e)
+	if err != nil {
+		return ""
+	}
+	m := make(map[string]security.EventPolicyRule)
 	for _, r := range rules {
 		m[r.Name] = r
-		if strings.HasPrefix(r.Name, "ext:") {
-			v1Ev.SetExtension(strings.TrimPrefix(r.Name, "ext:"), r.Name)
-			v03Ev.SetExtension(strings.TrimPrefix(r.Name, "ext:"), r.Name)
-		} else {
-			if setFunc, ok := v1Setters[strings.ToLower(r.Name)]; ok {
-				setFunc(r.Name)
-			}
-			if setFunc, ok := v03Setters[strings.ToLower(r.Name)]; ok {
-				setFunc(r.Name)
+	}
+	b.WriteString(fmt.Sprintf("  input.httpRequest.header[%q][_] == %q\n", "Ce-Specversion", e.SpecVersion()))
+	b.WriteString("  not ce_structured_mode\n")
+	for _, k := range events.SortedHeaderKeys(header) {
+		for _, v := range header[k] {
+			if r, ok := m[v]; ok {
+				writeMatches(b, fmt.Sprintf("input.httpRequest.header[%q][_]", k), r)
 			}
 		}
 	}
+	writeDataMatches(b, "input.httpRequest.body", rules)
+	return b.String()
+}
 
-	v1B := &strings.Builder{}
-	if msg, err := codecV1.Encode(context.Background(), *v1Ev); err == nil {
-		v1B.WriteString(fmt.Sprintf("  input.httpRequest.header[%q][_] == %q\n", "Ce-Specversion", cloudevents.CloudEventsVersionV1))
-		httpMsg := msg.(*cehttp.Message)
-		for k, h := range httpMsg.Header {
-			for _, v := range h {
-				if r, ok := m[v]; ok {
-					if r.ExactMatch != "" {
-						v1B.WriteString(fmt.Sprintf("  input.httpRequest.header[%q][_] == %q\n", k, r.ExactMatch))
-					}
-					if r.PrefixMatch != "" {
-						v1B.WriteString(fmt.Sprintf("  startswith(input.httpRequest.header[%q][_], %q)\n", k, r.PrefixMatch))
-					}
-					if r.SuffixMatch != "" {
-						v1B.WriteString(fmt.Sprintf("  endswith(input.httpRequest.header[%q][_], %q)\n", k, r.SuffixMatch))
-					}
-					if r.ContainsMatch != "" {
-						v1B.WriteString(fmt.Sprintf("  contains(input.httpRequest.header[%q][_], %q)\n", k, r.ContainsMatch))
-					}
-				}
-			}
+// structuredMatches matches the members of a structured event of the spec
+// version, in the same way as binaryMatches.
+func structuredMatches(version string, rules []security.EventPolicyRule) string {
+	b := &strings.Builder{}
+	b.WriteString(fmt.Sprintf("  e[%q] == %q\n", "specversion", version))
+	for _, r := range rules {
+		member, ok := structuredAttributes[version][strings.ToLower(r.Name)]
+		if strings.HasPrefix(r.Name, "ext:") {
+			member, ok = strings.TrimPrefix(r.Name, "ext:"), true
+		}
+		if ok {
+			writeMatches(b, fmt.Sprintf("e[%q]", member), r)
 		}
 	}
+	writeDataMatches(b, `e["data"]`, rules)
+	return b.String()
+}
 
-	v03B := &strings.Builder{}
-	if msg, err := codecV03.Encode(context.Background(), *v03Ev); err == nil {
-		v03B.WriteString(fmt.Sprintf("  input.httpRequest.header[%q][_] == %q\n", "Ce-Specversion", cloudevents.CloudEventsVersionV03))
-		httpMsg := msg.(*cehttp.Message)
-		for k, h := range httpMsg.Header {
-			for _, v := range h {
-				if r, ok := m[v]; ok {
-					if r.ExactMatch != "" {
-						v03B.WriteString(fmt.Sprintf("  input.httpRequest.header[%q][_] == %q\n", k, r.ExactMatch))
-					}
-					if r.PrefixMatch != "" {
-						v03B.WriteString(fmt.Sprintf("  startswith(input.httpRequest.header[%q][_], %q)\n", k, r.PrefixMatch))
-					}
-					if r.SuffixMatch != "" {
-						v03B.WriteString(fmt.Sprintf("  endswith(input.httpRequest.header[%q][_], %q)\n", k, r.SuffixMatch))
-					}
-					if r.ContainsMatch != "" {
-						v03B.WriteString(fmt.Sprintf("  contains(input.httpRequest.header[%q][_], %q)\n", k, r.ContainsMatch))
-					}
-				}
-			}
+// writeDataMatches matches the fields of the event data, found at ref.
+func writeDataMatches(b *strings.Builder, ref string, rules []security.EventPolicyRule) {
+	for _, r := range rules {
+		path, ok := r.DataPath()
+		if !ok {
+			continue
+		}
+		field := ref
+		for _, p := range path {
+			field += fmt.Sprintf("[%q]", p)
 		}
+		writeMatches(b, field, r)
 	}
+}
 
-	return strings.Join([]string{wrapRule(v1B.String()), wrapRule(v03B.String())}, "\n")
+func writeMatches(b *strings.Builder, ref string, r security.EventPolicyRule) {
+	if r.ExactMatch != "" {
+		b.WriteString(fmt.Sprintf("  %s == %q\n", ref, r.ExactMatch))
+	}
+	if r.PrefixMatch != "" {
+		b.WriteString(fmt.Sprintf("  startswith(%s, %q)\n", ref, r.PrefixMatch))
+	}
+	if r.SuffixMatch != "" {
+		b.WriteString(fmt.Sprintf("  endswith(%s, %q)\n", ref, r.SuffixMatch))
+	}
+	if r.ContainsMatch != "" {
+		b.WriteString(fmt.Sprintf("  contains(%s, %q)\n", ref, r.ContainsMatch))
+	}
 }
 
 func wrapRule(r string) string {
 	return fmt.Sprintf("allow {\n%s}\n", r)
 }
+
+func wrapStructuredRule(r string) string {
+	return fmt.Sprintf("ce_structured_event(e) {\n%s}\n", r)
+}
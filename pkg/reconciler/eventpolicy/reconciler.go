@@ -124,7 +124,7 @@ func (r *Reconciler) reconcile(ctx context.Context, p *security.EventPolicy) err
 }
 
 func (r *Reconciler) reconcileOpenPolicy(ctx context.Context, p *security.EventPolicy) (*security.OpenPolicy, error) {
-	rule := MakeOpenPolicyRule(p.Spec.Rules)
+	rule := MakeOpenPolicyRule(p.Spec.Rules, p.Spec.CheckPayload)
 	desired := &security.OpenPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            "ce-" + p.Name,
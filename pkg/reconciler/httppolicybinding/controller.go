@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httppolicybinding
+
+import (
+	"context"
+
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/logging"
+
+	bindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicybinding"
+	bindingreconciler "github.com/yolocs/knative-policy-binding/pkg/client/injection/reconciler/security/v1alpha2/httppolicybinding"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	ReconcilerName = "HTTPPolicyBindings"
+)
+
+// ClassConstructor constructs the reconciler of a binding class. The
+// reconciler enqueues bindings with the controller Impl.
+type ClassConstructor func(ctx context.Context, cmw configmap.Watcher, impl *controller.Impl) ClassReconciler
+
+// Classes is the registry of the binding classes the controller enforces.
+type Classes map[string]ClassConstructor
+
+// NewController returns the constructor of the controller handing the
+// HTTPPolicyBindings to the reconcilers of their classes.
+func NewController(classes Classes) injection.ControllerConstructor {
+	return func(
+		ctx context.Context,
+		cmw configmap.Watcher,
+	) *controller.Impl {
+		bindingInformer := bindinginformer.Get(ctx)
+
+		r := &Reconciler{
+			classes: make(map[string]ClassReconciler, len(classes)),
+		}
+		impl := bindingreconciler.NewImpl(ctx, r)
+
+		logging.FromContext(ctx).Info("Setting up event handlers")
+
+		bindingInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+		for class, newReconciler := range classes {
+			r.classes[class] = newReconciler(ctx, cmw, impl)
+		}
+		return impl
+	}
+}
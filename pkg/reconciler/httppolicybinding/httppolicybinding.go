@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httppolicybinding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+)
+
+// ClassReconciler reconciles the HTTPPolicyBindings of a binding class.
+type ClassReconciler interface {
+	ReconcileKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) pkgreconciler.Event
+}
+
+// ClassCleaner is implemented by ClassReconcilers removing what they created
+// for a binding once it switches to another class.
+type ClassCleaner interface {
+	// CleanupKind removes the resources of the class for the binding, as
+	// well as its status. It returns true while some of them remain.
+	CleanupKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) (bool, error)
+}
+
+// Reconciler hands the HTTPPolicyBindings to the reconcilers of their
+// classes, and reports the bindings no reconciler claims.
+type Reconciler struct {
+	classes map[string]ClassReconciler
+}
+
+func (r *Reconciler) ReconcileKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) pkgreconciler.Event {
+	class := b.GetAnnotations()[security.BindingClassAnnotationKey]
+	cr, ok := r.classes[class]
+	if !ok {
+		logging.FromContext(ctx).Info("No controller claims the binding class", zap.String("class", class))
+		b.Status.InitializeConditions()
+		b.Status.ObservedGeneration = b.Generation
+		b.Status.MarkBindingClassUnclaimed(class)
+		return r.handOver(ctx, b, class)
+	}
+	b.Status.MarkBindingClassClaimed()
+	// The new class enforces the binding before the others stop enforcing
+	// it.
+	if err := cr.ReconcileKind(ctx, b); err != nil {
+		return err
+	}
+	return r.handOver(ctx, b, class)
+}
+
+// handOver has the other classes remove what they created for the binding,
+// in case its class changed.
+func (r *Reconciler) handOver(ctx context.Context, b *v1alpha2.HTTPPolicyBinding, class string) pkgreconciler.Event {
+	var pending []string
+	for c, cr := range r.classes {
+		cc, ok := cr.(ClassCleaner)
+		if c == class || !ok {
+			continue
+		}
+		remaining, err := cc.CleanupKind(ctx, b)
+		if err != nil {
+			logging.FromContext(ctx).Error("Problem cleaning up previous binding class", zap.String("class", c), zap.Error(err))
+			b.Status.MarkClassHandoverFailed("CleanupFailure", "Binding class %q failed to remove its resources: %v", c, err)
+			return fmt.Errorf("Failed to clean up binding class %q: %w", c, err)
+		}
+		if remaining {
+			pending = append(pending, c)
+		}
+	}
+
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		b.Status.MarkClassHandoverInProgress(pending)
+	} else if b.Status.GetCondition(v1alpha2.HTTPPolicyBindingConditionClassHandover) != nil {
+		b.Status.MarkClassHandoverComplete()
+	}
+	return nil
+}
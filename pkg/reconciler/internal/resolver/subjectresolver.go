@@ -22,13 +22,19 @@ import (
 	"errors"
 	"fmt"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	pkgapisduck "knative.dev/pkg/apis/duck"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/client/injection/ducks/duck/v1/conditions"
+	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+	statefulsetinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/statefulset"
+	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/tracker"
 )
@@ -37,6 +43,11 @@ import (
 type SubjectResolver struct {
 	tracker         tracker.Interface
 	informerFactory pkgapisduck.InformerFactory
+
+	// Listers for the well-known kinds whose spec is needed to resolve them.
+	serviceLister     corev1listers.ServiceLister
+	deploymentLister  appsv1listers.DeploymentLister
+	statefulSetLister appsv1listers.StatefulSetLister
 }
 
 // NewSubjectResolver constructs a new PodspecableResolver with context and a callback
@@ -52,11 +63,49 @@ func NewSubjectResolver(ctx context.Context, callback func(types.NamespacedName)
 		},
 	}
 
+	serviceInformer := serviceinformer.Get(ctx)
+	deploymentInformer := deploymentinformer.Get(ctx)
+	statefulSetInformer := statefulsetinformer.Get(ctx)
+	ret.serviceLister = serviceInformer.Lister()
+	ret.deploymentLister = deploymentInformer.Lister()
+	ret.statefulSetLister = statefulSetInformer.Lister()
+	// The duck informers already notify the tracker, but the listers may lag
+	// behind them.
+	serviceInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(ret.tracker.OnChanged, corev1.SchemeGroupVersion.WithKind("Service"))))
+	deploymentInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(ret.tracker.OnChanged, appsv1.SchemeGroupVersion.WithKind("Deployment"))))
+	statefulSetInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(ret.tracker.OnChanged, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))))
+
 	return ret
 }
 
+// Subject is a resolved policy subject.
+type Subject struct {
+	// Namespace of the subject.
+	Namespace string
+
+	// Workloads are the podspecables running the subject, referred to by
+	// name or selector. Binding implementations injecting into the
+	// podspecables bind each of them.
+	Workloads []tracker.Reference
+
+	// Selector selects the pods of the subject by labels. It's nil if the
+	// subject doesn't select pods by labels.
+	Selector *metav1.LabelSelector
+}
+
+// Reference refers to the pods of the subject.
+func (s *Subject) Reference() *tracker.Reference {
+	return &tracker.Reference{
+		Namespace: s.Namespace,
+		Selector:  s.Selector.DeepCopy(),
+	}
+}
+
 // ResolveFromRef resolves podspecable from the reference.
-func (r *SubjectResolver) ResolveFromRef(ref *corev1.ObjectReference, parent interface{}) (*tracker.Reference, error) {
+func (r *SubjectResolver) ResolveFromRef(ref *corev1.ObjectReference, parent interface{}) (*Subject, error) {
 	if ref == nil {
 		return nil, errors.New("ref is nil")
 	}
@@ -84,15 +133,19 @@ func (r *SubjectResolver) ResolveFromRef(ref *corev1.ObjectReference, parent int
 	// Parse the annotation?
 	subRaw, ok := kr.Annotations["security.knative.dev/authorizableOn"]
 	if !ok {
-		return nil, errors.New("the reference is not an authorizable; expecting annotation 'security.knative.dev/authorizableOn'")
+		// The annotation overrides the resolution of the well-known kinds.
+		return r.resolveWellKnown(kr, parent)
 	}
 	// Handle this special case where the object itself is already podspecable.
 	if subRaw == "self" {
-		return &tracker.Reference{
-			APIVersion: kr.APIVersion,
-			Kind:       kr.Kind,
-			Name:       kr.Name,
-			Namespace:  kr.Namespace,
+		return &Subject{
+			Namespace: kr.Namespace,
+			Workloads: []tracker.Reference{{
+				APIVersion: kr.APIVersion,
+				Kind:       kr.Kind,
+				Name:       kr.Name,
+				Namespace:  kr.Namespace,
+			}},
 			// Populate labels in case the binding implementation only supports labels.
 			Selector: &metav1.LabelSelector{
 				MatchLabels: kr.GetLabels(),
@@ -109,5 +162,23 @@ func (r *SubjectResolver) ResolveFromRef(ref *corev1.ObjectReference, parent int
 		t.Namespace = ref.Namespace
 	}
 
-	return &t, nil
+	return &Subject{
+		Namespace: t.Namespace,
+		Workloads: []tracker.Reference{t},
+		Selector:  t.Selector.DeepCopy(),
+	}, nil
+}
+
+// ResolveWorkloads resolves the subject selecting the workloads in the
+// namespace by labels. All of them are selected if the selector is nil.
+// The workloads aren't resolved, binding implementations injecting into them
+// bind every kind by the selector.
+func ResolveWorkloads(namespace string, selector *metav1.LabelSelector) *Subject {
+	if selector == nil {
+		selector = &metav1.LabelSelector{}
+	}
+	return &Subject{
+		Namespace: namespace,
+		Selector:  selector.DeepCopy(),
+	}
 }
@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/tracker"
+)
+
+var (
+	serviceGK           = schema.GroupKind{Kind: "Service"}
+	deploymentGK        = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	statefulSetGK       = schema.GroupKind{Group: "apps", Kind: "StatefulSet"}
+	knativeServiceGK    = schema.GroupKind{Group: "serving.knative.dev", Kind: "Service"}
+	knativeBrokerGK     = schema.GroupKind{Group: "eventing.knative.dev", Kind: "Broker"}
+	deploymentTypeMeta  = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	statefulSetTypeMeta = appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+)
+
+const (
+	// knativeServiceLabelKey labels the workloads of Knative Services.
+	knativeServiceLabelKey = "serving.knative.dev/service"
+
+	// The broker ingress deployment is labeled with the broker name and role.
+	brokerLabelKey     = "eventing.knative.dev/broker"
+	brokerRoleLabelKey = "eventing.knative.dev/brokerRole"
+	brokerRoleIngress  = "ingress"
+)
+
+var errNotAuthorizable = errors.New("the reference is not an authorizable; expecting a well-known kind or annotation 'security.knative.dev/authorizableOn'")
+
+// resolveWellKnown resolves the subject of the kinds authorizable without the
+// 'security.knative.dev/authorizableOn' annotation:
+//   - a core Service resolves to the Deployments and StatefulSets whose pods it
+//     selects;
+//   - a Deployment or StatefulSet resolves to itself;
+//   - a Knative Service resolves to itself, its pods are labeled with its name;
+//   - a Broker resolves to its ingress Deployment.
+func (r *SubjectResolver) resolveWellKnown(kr *duckv1.KResource, parent interface{}) (*Subject, error) {
+	switch kr.GroupVersionKind().GroupKind() {
+	case serviceGK:
+		svc, err := r.serviceLister.Services(kr.Namespace).Get(kr.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service %s/%s: %w", kr.Namespace, kr.Name, err)
+		}
+		if len(svc.Spec.Selector) == 0 {
+			return nil, fmt.Errorf("service %s/%s doesn't select pods", kr.Namespace, kr.Name)
+		}
+		return r.workloadsSubject(kr.Namespace, svc.Spec.Selector, parent)
+
+	case deploymentGK:
+		d, err := r.deploymentLister.Deployments(kr.Namespace).Get(kr.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %s/%s: %w", kr.Namespace, kr.Name, err)
+		}
+		return selfSubject(kr, d.Spec.Selector)
+
+	case statefulSetGK:
+		ss, err := r.statefulSetLister.StatefulSets(kr.Namespace).Get(kr.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statefulset %s/%s: %w", kr.Namespace, kr.Name, err)
+		}
+		return selfSubject(kr, ss.Spec.Selector)
+
+	case knativeServiceGK:
+		return selfSubject(kr, &metav1.LabelSelector{
+			MatchLabels: map[string]string{knativeServiceLabelKey: kr.Name},
+		})
+
+	case knativeBrokerGK:
+		return r.workloadsSubject(kr.Namespace, map[string]string{
+			brokerLabelKey:     kr.Name,
+			brokerRoleLabelKey: brokerRoleIngress,
+		}, parent)
+	}
+	return nil, errNotAuthorizable
+}
+
+// selfSubject refers to the podspecable itself and selects its pods.
+func selfSubject(kr *duckv1.KResource, selector *metav1.LabelSelector) (*Subject, error) {
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return nil, fmt.Errorf("%s %s/%s doesn't select pods by labels", kr.Kind, kr.Namespace, kr.Name)
+	}
+	return &Subject{
+		Namespace: kr.Namespace,
+		Workloads: []tracker.Reference{{
+			APIVersion: kr.APIVersion,
+			Kind:       kr.Kind,
+			Namespace:  kr.Namespace,
+			Name:       kr.Name,
+		}},
+		Selector: &metav1.LabelSelector{
+			MatchLabels: selector.MatchLabels,
+		},
+	}, nil
+}
+
+// workloadsSubject selects the pods labeled with the given labels and refers to
+// the Deployments and StatefulSets whose pod template carries them. The parent
+// tracks all of them in the namespace so that it's resolved again when they
+// change.
+func (r *SubjectResolver) workloadsSubject(namespace string, podLabels map[string]string, parent interface{}) (*Subject, error) {
+	sub := &Subject{
+		Namespace: namespace,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: podLabels,
+		},
+	}
+	selector := labels.SelectorFromSet(podLabels)
+
+	deployments, err := r.deploymentLister.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments {
+		if selector.Matches(labels.Set(d.Spec.Template.Labels)) {
+			sub.Workloads = append(sub.Workloads, workloadReference(deploymentTypeMeta, d.ObjectMeta))
+		}
+	}
+	statefulSets, err := r.statefulSetLister.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, ss := range statefulSets {
+		if selector.Matches(labels.Set(ss.Spec.Template.Labels)) {
+			sub.Workloads = append(sub.Workloads, workloadReference(statefulSetTypeMeta, ss.ObjectMeta))
+		}
+	}
+
+	for _, gvk := range []schema.GroupVersionKind{deploymentTypeMeta, statefulSetTypeMeta} {
+		apiVersion, kind := gvk.ToAPIVersionAndKind()
+		ref := tracker.Reference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Namespace:  namespace,
+			Selector:   &metav1.LabelSelector{},
+		}
+		if err := r.tracker.TrackReference(ref, parent); err != nil {
+			return nil, fmt.Errorf("failed to track %s in %s: %w", kind, namespace, err)
+		}
+	}
+	return sub, nil
+}
+
+// workloadReference refers to the workload by name.
+func workloadReference(gvk schema.GroupVersionKind, meta metav1.ObjectMeta) tracker.Reference {
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return tracker.Reference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  meta.Namespace,
+		Name:       meta.Name,
+	}
+}
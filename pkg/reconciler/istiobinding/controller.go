@@ -25,12 +25,16 @@ import (
 	"knative.dev/pkg/tracker"
 
 	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	clusterpolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/clusterhttppolicy"
+	eventpolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/eventpolicy"
 	policyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicy"
 	bindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicybinding"
-	bindingreconciler "github.com/yolocs/knative-policy-binding/pkg/client/injection/reconciler/security/v1alpha2/httppolicybinding"
 	istioclient "github.com/yolocs/knative-policy-binding/pkg/client/istio/injection/client"
 	istioauthzinformer "github.com/yolocs/knative-policy-binding/pkg/client/istio/injection/informers/security/v1beta1/authorizationpolicy"
+	istioauthninformer "github.com/yolocs/knative-policy-binding/pkg/client/istio/injection/informers/security/v1beta1/requestauthentication"
+	"github.com/yolocs/knative-policy-binding/pkg/httppolicy"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler/httppolicybinding"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/internal/resolver"
 )
 
@@ -40,39 +44,50 @@ const (
 	controllerAgentName = "istiobinding-controller"
 )
 
-// NewController initializes the controller and is called by the generated code
-// Registers event handlers to enqueue events
-func NewController(
+// NewClassReconciler initializes the reconciler of the istio binding class and
+// registers event handlers to enqueue bindings with impl.
+func NewClassReconciler(
 	ctx context.Context,
 	cmw configmap.Watcher,
-) *controller.Impl {
+	impl *controller.Impl,
+) httppolicybinding.ClassReconciler {
 
 	bindingInformer := bindinginformer.Get(ctx)
 	policyInformer := policyinformer.Get(ctx)
+	clusterPolicyInformer := clusterpolicyinformer.Get(ctx)
+	eventPolicyInformer := eventpolicyinformer.Get(ctx)
 	istioauthzInformer := istioauthzinformer.Get(ctx)
+	istioauthnInformer := istioauthninformer.Get(ctx)
 
 	r := &Reconciler{
 		Base:                reconciler.NewBase(ctx, controllerAgentName, cmw),
 		policybindingLister: bindingInformer.Lister(),
 		policyLister:        policyInformer.Lister(),
+		clusterPolicyLister: clusterPolicyInformer.Lister(),
+		eventPolicyLister:   eventPolicyInformer.Lister(),
 		istioauthzLister:    istioauthzInformer.Lister(),
+		istioauthnLister:    istioauthnInformer.Lister(),
 		istioClientSet:      istioclient.Get(ctx),
 	}
-	impl := bindingreconciler.NewImpl(ctx, r)
 
 	r.Logger.Info("Setting up event handlers")
 
-	bindingInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
-
 	istioauthzInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.Filter(v1alpha2.SchemeGroupVersion.WithKind("HTTPPolicyBinding")),
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	istioauthnInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.Filter(v1alpha2.SchemeGroupVersion.WithKind("HTTPPolicyBinding")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
 	r.subjectResolver = resolver.NewSubjectResolver(ctx, impl.EnqueueKey)
 	r.policyTracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
 
-	policyInformer.Informer().AddEventHandler(controller.HandleAll(r.policyTracker.OnChanged))
+	policyInformer.Informer().AddEventHandler(controller.HandleAll(httppolicy.OnChanged(r.policyTracker)))
+	clusterPolicyInformer.Informer().AddEventHandler(controller.HandleAll(httppolicy.OnChanged(r.policyTracker)))
+	eventPolicyInformer.Informer().AddEventHandler(controller.HandleAll(httppolicy.OnChanged(r.policyTracker)))
 
-	return impl
+	return r
 }
@@ -18,33 +18,34 @@ package istiobinding
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/pkg/tracker"
 
+	istiov1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
 	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
 	istioclientset "github.com/yolocs/knative-policy-binding/pkg/client/istio/clientset/versioned"
 	istiolisters "github.com/yolocs/knative-policy-binding/pkg/client/istio/listers/security/v1beta1"
 	securitylisters "github.com/yolocs/knative-policy-binding/pkg/client/listers/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/httppolicy"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/internal/resolver"
-	istiosecurityv1beta1 "istio.io/api/security/v1beta1"
-	istiotypev1beta1 "istio.io/api/type/v1beta1"
-	istiov1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 )
 
 const (
-	bindingReconcileError     = "HTTPPolicyBindingReconcileError"
-	bindingReconciled         = "HTTPPolicyBindingReconciled"
-	bindingClassAnnotationKey = "security.knative.dev/binding.class"
-	bindingClass              = "istio"
+	bindingReconcileError = "HTTPPolicyBindingReconcileError"
+	bindingReconciled     = "HTTPPolicyBindingReconciled"
 )
 
 type Reconciler struct {
@@ -52,7 +53,10 @@ type Reconciler struct {
 
 	policybindingLister securitylisters.HTTPPolicyBindingLister
 	policyLister        securitylisters.HTTPPolicyLister
+	clusterPolicyLister securitylisters.ClusterHTTPPolicyLister
+	eventPolicyLister   securitylisters.EventPolicyLister
 	istioauthzLister    istiolisters.AuthorizationPolicyLister
+	istioauthnLister    istiolisters.RequestAuthenticationLister
 
 	istioClientSet istioclientset.Interface
 
@@ -61,52 +65,139 @@ type Reconciler struct {
 }
 
 func (r *Reconciler) ReconcileKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) pkgreconciler.Event {
-	if b.GetAnnotations()[bindingClassAnnotationKey] != bindingClass {
-		logging.FromContext(ctx).Info("Not reconciling binding, cause it's not mine", zap.String("HTTPPolicyBinding", b.Name))
-		return nil
-	}
-
 	logging.FromContext(ctx).Debug("Reconciling", zap.Any("HTTPPolicyBinding", b))
 	b.Status.InitializeConditions()
 	b.Status.ObservedGeneration = b.Generation
 
-	sub, err := r.subjectResolver.ResolveFromRef(b.Spec.Subject, b)
+	var sub *resolver.Subject
+	if b.Spec.IsNamespaceWide() {
+		sub = resolver.ResolveWorkloads(b.Namespace, b.Spec.Workloads.Selector)
+		if len(sub.Selector.MatchExpressions) > 0 {
+			logging.FromContext(ctx).Error("Binding workloads selector has match expressions")
+			b.Status.MarkBindingSubjectResolvingFaiulre("SubjectNotLabelSelector", "Istio AuthorizationPolicy can only select workload by labels")
+			return errors.New("Failed to reconcile HTTP policy binding: workloads selector has match expressions")
+		}
+	} else {
+		var err error
+		sub, err = r.subjectResolver.ResolveFromRef(b.Spec.Subject, b)
+		if err != nil {
+			logging.FromContext(ctx).Error("Problem resolving binding subject", zap.Error(err))
+			b.Status.MarkBindingSubjectResolvingFaiulre("SubjectResolvingFailure", "%v", err)
+			return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+		}
+		if sub.Selector == nil || len(sub.Selector.MatchLabels) == 0 {
+			logging.FromContext(ctx).Error("Resolved binding target is not a label selector")
+			b.Status.MarkBindingSubjectResolvingFaiulre("SubjectNotLabelSelector", "Istio AuthorizationPolicy can only select workload by labels")
+			return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+		}
+	}
+	b.Status.MarkBindingSubjectResolved(sub.Reference())
+
+	policies, spec, err := httppolicy.Resolve(r.policyLister, r.clusterPolicyLister, r.eventPolicyLister, r.policyTracker, b)
 	if err != nil {
-		logging.FromContext(ctx).Error("Problem resolving binding subject", zap.Error(err))
-		b.Status.MarkBindingSubjectResolvingFaiulre("SubjectResolvingFailure", "%v", err)
-		return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+		logging.FromContext(ctx).Error("Problem resolving policies", zap.Error(err))
+		b.Status.MarkBindingUnavailable("GetPolicyFailure", err.Error())
+		return fmt.Errorf("Failed to resolve the referencing policies: %w", err)
 	}
-	if sub.Selector == nil || len(sub.Selector.MatchLabels) == 0 {
-		logging.FromContext(ctx).Error("Resolved binding target is not a label selector")
-		b.Status.MarkBindingSubjectResolvingFaiulre("SubjectNotLabelSelector", "Istio AuthorizationPolicy can only select workload by labels")
-		return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+	b.Status.MarkPoliciesResolved(policies)
+
+	if spec.Rego != "" {
+		logging.FromContext(ctx).Error("Binding policy has Rego rules")
+		b.Status.MarkAuthorizationPolicyFailed("RegoNotSupported", "Istio AuthorizationPolicy can't enforce Rego rules, use the %q binding class instead", security.OPABindingClass)
+		b.Status.MarkBindingUnavailable("RegoNotSupported", "Istio AuthorizationPolicy can't enforce Rego rules")
+		return errors.New("Failed to reconcile HTTP policy binding: policy has Rego rules")
 	}
-	b.Status.MarkBindingSubjectResolved(sub)
 
-	p, err := r.policyLister.HTTPPolicies(b.Spec.Policy.Namespace).Get(b.Spec.Policy.Name)
+	ra, err := r.reconcileRequestAuthentication(ctx, b, sub, spec)
 	if err != nil {
-		logging.FromContext(ctx).Error("Problem getting policy", zap.Error(err))
-		b.Status.MarkBindingUnavailable("GetPolicyFailure", err.Error())
-		return fmt.Errorf("Failed to get the referencing policy: %w", err)
+		logging.FromContext(ctx).Error("Problem reconciling Istio RequestAuthentication", zap.Error(err))
+		b.Status.MarkRequestAuthenticationFailed("RequestAuthenticationFailure", "%v", err)
+		b.Status.MarkBindingUnavailable("RequestAuthenticationFailure", err.Error())
+		return err
 	}
-	r.policyTracker.Track(*b.Spec.Policy, b)
 
-	if err := r.reconcileIstioAuthzPolicies(ctx, b, sub, p); err != nil {
+	authzs, err := r.reconcileIstioAuthzPolicies(ctx, b, sub, spec)
+	if err != nil {
 		logging.FromContext(ctx).Error("Problem reconciling Istio AuthorizationPolicy", zap.Error(err))
-		b.Status.MarkBindingSubjectResolvingFaiulre("IstioAuthorizationPolicyFailure", "%v", err)
+		b.Status.MarkAuthorizationPolicyFailed("AuthorizationPolicyFailure", "%v", err)
+		b.Status.MarkBindingUnavailable("AuthorizationPolicyFailure", err.Error())
 		return err
 	}
+	b.Status.MarkAuthorizationPolicyReady()
+	b.Status.SetGeneratedObjects(generatedObjects(ra, authzs))
 
 	b.Status.MarkBindingAvailable()
 	return nil
 }
 
+// generatedObjects references the Istio objects enforcing the binding.
+func generatedObjects(ra *istiov1beta1.RequestAuthentication, authzs []*istiov1beta1.AuthorizationPolicy) []corev1.ObjectReference {
+	apiVersion := istiov1beta1.SchemeGroupVersion.String()
+	var refs []corev1.ObjectReference
+	if ra != nil {
+		refs = append(refs, corev1.ObjectReference{
+			APIVersion: apiVersion,
+			Kind:       "RequestAuthentication",
+			Namespace:  ra.Namespace,
+			Name:       ra.Name,
+		})
+	}
+	for _, p := range authzs {
+		refs = append(refs, corev1.ObjectReference{
+			APIVersion: apiVersion,
+			Kind:       "AuthorizationPolicy",
+			Namespace:  p.Namespace,
+			Name:       p.Name,
+		})
+	}
+	return refs
+}
+
+// CleanupKind implements httppolicybinding.ClassCleaner. Istio stops enforcing
+// the policy as soon as the AuthorizationPolicies and RequestAuthentication are
+// deleted.
+func (r *Reconciler) CleanupKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) (bool, error) {
+	b.Status.ClearRequestAuthenticationReady()
+	b.Status.ClearAuthorizationPolicyReady()
+	b.Status.SetGeneratedObjects(nil)
+
+	authzs, err := r.istioauthzLister.AuthorizationPolicies(b.Namespace).List(labels.Everything())
+	if err != nil {
+		return false, fmt.Errorf("Failed to list Istio AuthorizationPolicies: %w", err)
+	}
+	authns, err := r.istioauthnLister.RequestAuthentications(b.Namespace).List(labels.Everything())
+	if err != nil {
+		return false, fmt.Errorf("Failed to list Istio RequestAuthentications: %w", err)
+	}
+
+	remaining := false
+	for _, p := range authzs {
+		if !metav1.IsControlledBy(p, b) {
+			continue
+		}
+		remaining = true
+		if err := r.istioClientSet.SecurityV1beta1().AuthorizationPolicies(p.Namespace).Delete(p.Name, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return true, fmt.Errorf("Failed to delete Istio AuthorizationPolicy: %w", err)
+		}
+	}
+	for _, ra := range authns {
+		if !metav1.IsControlledBy(ra, b) {
+			continue
+		}
+		remaining = true
+		if err := r.istioClientSet.SecurityV1beta1().RequestAuthentications(ra.Namespace).Delete(ra.Name, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return true, fmt.Errorf("Failed to delete Istio RequestAuthentication: %w", err)
+		}
+	}
+	return remaining, nil
+}
+
 func (r *Reconciler) reconcileIstioAuthzPolicies(
 	ctx context.Context,
 	b *v1alpha2.HTTPPolicyBinding,
-	sub *tracker.Reference,
-	policy *v1alpha2.HTTPPolicy,
-) pkgreconciler.Event {
+	sub *resolver.Subject,
+	spec *v1alpha2.HTTPPolicySpec,
+) ([]*istiov1beta1.AuthorizationPolicy, error) {
 
 	// No need: https://istio.io/docs/reference/config/security/authorization-policy/#AuthorizationPolicy
 	// rejectPolicy := &istiov1beta1.AuthorizationPolicy{
@@ -115,8 +206,8 @@ func (r *Reconciler) reconcileIstioAuthzPolicies(
 	// 		Namespace:       sub.Namespace,
 	// 		OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
 	// 	},
-	// 	Spec: istiosecurityv1beta1.AuthorizationPolicy{
-	// 		Selector: &istiotypev1beta1.WorkloadSelector{
+	// 	Spec: istiov1beta1.AuthorizationPolicySpec{
+	// 		Selector: &istiov1beta1.WorkloadSelector{
 	// 			MatchLabels: sub.Selector.MatchLabels,
 	// 		},
 	// 	},
@@ -125,75 +216,213 @@ func (r *Reconciler) reconcileIstioAuthzPolicies(
 	// 	return err
 	// }
 
-	allowPolicy := &istiov1beta1.AuthorizationPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            b.Name,
-			Namespace:       sub.Namespace,
-			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
-		},
-		Spec: istiosecurityv1beta1.AuthorizationPolicy{
-			Selector: &istiotypev1beta1.WorkloadSelector{
-				MatchLabels: sub.Selector.MatchLabels,
+	rules := istioAuthzRulesFromPolicy(spec)
+	var authzs []*istiov1beta1.AuthorizationPolicy
+	for _, action := range authzActions {
+		name := authzPolicyName(b, action)
+		if !needsAuthzPolicy(spec, action, rules[action]) {
+			if err := r.deleteIstioAuthz(b, sub.Namespace, name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		desired := &istiov1beta1.AuthorizationPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       sub.Namespace,
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
+			},
+			Spec: istiov1beta1.AuthorizationPolicySpec{
+				Selector: workloadSelector(sub),
+				Rules:    rules[action],
 			},
-			Rules: istioAuthzRulesFromPolicy(policy),
-		},
+		}
+		// ALLOW is the default, leave it out for Istio versions without actions.
+		if action != istiov1beta1.AuthorizationPolicyActionAllow {
+			desired.Spec.Action = action
+		}
+		authz, err := r.reconcileIstioAuthz(ctx, b, desired)
+		if err != nil {
+			return nil, err
+		}
+		authzs = append(authzs, authz)
 	}
-	return r.reconcileIstioAuthz(ctx, allowPolicy)
+	return authzs, nil
 }
 
-func (r *Reconciler) reconcileIstioAuthz(ctx context.Context, desired *istiov1beta1.AuthorizationPolicy) pkgreconciler.Event {
+// workloadSelector returns the selector of the Istio policies for the subject.
+// Policies without a selector apply to all the workloads in the namespace.
+func workloadSelector(sub *resolver.Subject) *istiov1beta1.WorkloadSelector {
+	if len(sub.Selector.MatchLabels) == 0 {
+		return nil
+	}
+	return &istiov1beta1.WorkloadSelector{
+		MatchLabels: sub.Selector.MatchLabels,
+	}
+}
+
+// Istio evaluates DENY policies before ALLOW policies, so each action gets
+// its own AuthorizationPolicy.
+var authzActions = []istiov1beta1.AuthorizationPolicyAction{
+	istiov1beta1.AuthorizationPolicyActionAllow,
+	istiov1beta1.AuthorizationPolicyActionDeny,
+	istiov1beta1.AuthorizationPolicyActionAudit,
+}
+
+// needsAuthzPolicy tells if the action needs an AuthorizationPolicy. An ALLOW
+// AuthorizationPolicy is needed as long as the policy has ALLOW rules, even
+// if none of them can match, or no rules at all, so that everything else is
+// denied.
+func needsAuthzPolicy(spec *v1alpha2.HTTPPolicySpec, action istiov1beta1.AuthorizationPolicyAction, rules []*istiov1beta1.Rule) bool {
+	if action != istiov1beta1.AuthorizationPolicyActionAllow {
+		return len(rules) > 0
+	}
+	if len(spec.Rules) == 0 {
+		return true
+	}
+	for _, r := range spec.Rules {
+		if authzAction(r.Action) == istiov1beta1.AuthorizationPolicyActionAllow {
+			return true
+		}
+	}
+	return false
+}
+
+func authzPolicyName(b *v1alpha2.HTTPPolicyBinding, action istiov1beta1.AuthorizationPolicyAction) string {
+	switch action {
+	case istiov1beta1.AuthorizationPolicyActionDeny:
+		return b.Name + "-deny"
+	case istiov1beta1.AuthorizationPolicyActionAudit:
+		return b.Name + "-audit"
+	}
+	return b.Name
+}
+
+func (r *Reconciler) deleteIstioAuthz(b *v1alpha2.HTTPPolicyBinding, namespace, name string) error {
+	existing, err := r.istioauthzLister.AuthorizationPolicies(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Failed to get Istio AuthorizationPolicy: %w", err)
+	}
+	if !metav1.IsControlledBy(existing, b) {
+		return nil
+	}
+	if err := r.istioClientSet.SecurityV1beta1().AuthorizationPolicies(namespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete Istio AuthorizationPolicy: %w", err)
+	}
+	return nil
+}
+
+// reconcileIstioAuthz makes the AuthorizationPolicy match the desired one,
+// and returns it. AuthorizationPolicies created for other purposes are left
+// untouched.
+func (r *Reconciler) reconcileIstioAuthz(ctx context.Context, b *v1alpha2.HTTPPolicyBinding, desired *istiov1beta1.AuthorizationPolicy) (*istiov1beta1.AuthorizationPolicy, error) {
 	existing, err := r.istioauthzLister.AuthorizationPolicies(desired.Namespace).Get(desired.Name)
 	if apierrs.IsNotFound(err) {
 		existing, err = r.istioClientSet.SecurityV1beta1().AuthorizationPolicies(desired.Namespace).Create(desired)
 		if err != nil {
-			return fmt.Errorf("Failed to create Istio AuthorizationPolicy: %w", err)
-		} else if err != nil {
-			return fmt.Errorf("Failed to get Istio AuthorizationPolicy: %w", err)
+			return nil, fmt.Errorf("Failed to create Istio AuthorizationPolicy: %w", err)
 		}
+		return existing, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to get Istio AuthorizationPolicy: %w", err)
 	}
 
-	if !equality.Semantic.DeepDerivative(desired.Spec, existing.Spec) {
+	if !metav1.IsControlledBy(existing, b) {
+		return nil, fmt.Errorf("Istio AuthorizationPolicy %q is not owned by the binding", existing.Name)
+	}
+	if existing.DeletionTimestamp != nil {
+		return nil, fmt.Errorf("Istio AuthorizationPolicy %q is being deleted", existing.Name)
+	}
+
+	// Istio doesn't default the spec, so any difference is a drift.
+	if !equality.Semantic.DeepEqual(desired.Spec, existing.Spec) {
+		logging.FromContext(ctx).Info("Istio AuthorizationPolicy drifted", zap.String("name", existing.Name))
 		// Don't modify the informers copy.
 		cp := existing.DeepCopy()
 		cp.Spec = desired.Spec
 		existing, err = r.istioClientSet.SecurityV1beta1().AuthorizationPolicies(desired.Namespace).Update(cp)
 		if err != nil {
-			return fmt.Errorf("Failed to update Istio AuthorizationPolicy: %w", err)
+			return nil, fmt.Errorf("Failed to update Istio AuthorizationPolicy: %w", err)
 		}
 	}
 
-	return nil
+	return existing, nil
 }
 
-func istioAuthzRulesFromPolicy(policy *v1alpha2.HTTPPolicy) []*istiosecurityv1beta1.Rule {
-	var ret []*istiosecurityv1beta1.Rule
-	for _, r := range policy.Spec.Rules {
-		ir := &istiosecurityv1beta1.Rule{}
-		ir.From = []*istiosecurityv1beta1.Rule_From{
-			{Source: &istiosecurityv1beta1.Source{RequestPrincipals: r.Auth.Principals}},
+// istioAuthzRulesFromPolicy translates the policy rules grouped by action.
+func istioAuthzRulesFromPolicy(spec *v1alpha2.HTTPPolicySpec) map[istiov1beta1.AuthorizationPolicyAction][]*istiov1beta1.Rule {
+	ret := map[istiov1beta1.AuthorizationPolicyAction][]*istiov1beta1.Rule{}
+	for _, r := range spec.Rules {
+		ir := &istiov1beta1.Rule{}
+		// Fields in a source are ANDed, so the request principals are
+		// required with every source.
+		for _, src := range r.From {
+			ir.From = append(ir.From, &istiov1beta1.RuleFrom{
+				Source: &istiov1beta1.Source{
+					IPBlocks:          src.IPBlocks,
+					Namespaces:        src.Namespaces,
+					Principals:        src.Principals,
+					RequestPrincipals: r.Auth.Principals,
+				},
+			})
+		}
+		if len(r.From) == 0 && len(r.Auth.Principals) > 0 {
+			ir.From = []*istiov1beta1.RuleFrom{
+				{Source: &istiov1beta1.Source{RequestPrincipals: r.Auth.Principals}},
+			}
 		}
 		for _, cl := range r.Auth.Claims {
-			ir.When = append(ir.When, &istiosecurityv1beta1.Condition{
-				Key:    fmt.Sprintf("request.auth.cliams[%s]", cl.Key),
-				Values: cl.Values,
+			ir.When = append(ir.When, &istiov1beta1.Condition{
+				Key:       fmt.Sprintf("request.auth.claims[%s]", cl.Key),
+				Values:    cl.Values,
+				NotValues: cl.NotValues,
 			})
 		}
 		for _, h := range r.Headers {
-			ir.When = append(ir.When, &istiosecurityv1beta1.Condition{
-				Key:    fmt.Sprintf("request.headers[%s]", h.Key),
-				Values: h.Values,
+			ir.When = append(ir.When, &istiov1beta1.Condition{
+				Key:       fmt.Sprintf("request.headers[%s]", h.Key),
+				Values:    h.Values,
+				NotValues: h.NotValues,
 			})
 		}
+
+		var ops []*istiov1beta1.Operation
 		for _, op := range r.Operations {
-			ir.To = append(ir.To, &istiosecurityv1beta1.Rule_To{
-				Operation: &istiosecurityv1beta1.Operation{
-					Hosts:   op.Hosts,
-					Methods: op.Methods,
-					Paths:   op.Paths,
-				},
+			ops = append(ops, &istiov1beta1.Operation{
+				Hosts:      op.Hosts,
+				NotHosts:   op.NotHosts,
+				Methods:    op.Methods,
+				NotMethods: op.NotMethods,
+				Paths:      op.Paths,
+				NotPaths:   op.NotPaths,
 			})
 		}
-		ret = append(ret, ir)
+		if requiresJWT(&r) {
+			var ok bool
+			ops, ok = triggeredOperations(ops, spec.JWT.TriggerRules)
+			if !ok {
+				// The rule can't match any JWT triggered request.
+				continue
+			}
+		}
+		for _, op := range ops {
+			ir.To = append(ir.To, &istiov1beta1.RuleTo{Operation: op})
+		}
+		action := authzAction(r.Action)
+		ret[action] = append(ret[action], ir)
 	}
 	return ret
 }
+
+func authzAction(a v1alpha2.RuleAction) istiov1beta1.AuthorizationPolicyAction {
+	switch a {
+	case v1alpha2.RuleActionDeny:
+		return istiov1beta1.AuthorizationPolicyActionDeny
+	case v1alpha2.RuleActionAudit:
+		return istiov1beta1.AuthorizationPolicyActionAudit
+	}
+	return istiov1beta1.AuthorizationPolicyActionAllow
+}
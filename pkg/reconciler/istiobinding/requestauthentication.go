@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istiobinding
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+
+	istiov1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler/internal/resolver"
+)
+
+// reconcileRequestAuthentication makes Istio verify JWT as configured by the
+// policy spec, and returns the RequestAuthentication. Without a JWT config, the
+// RequestAuthentication previously created for the binding is removed.
+func (r *Reconciler) reconcileRequestAuthentication(
+	ctx context.Context,
+	b *v1alpha2.HTTPPolicyBinding,
+	sub *resolver.Subject,
+	spec *v1alpha2.HTTPPolicySpec,
+) (*istiov1beta1.RequestAuthentication, error) {
+	jwt := spec.JWT
+	if jwt.JwksURI == "" && jwt.Jwks == "" {
+		b.Status.ClearRequestAuthenticationReady()
+		return nil, r.deleteRequestAuthentication(b, sub.Namespace)
+	}
+	if jwt.Issuer == "" {
+		return nil, fmt.Errorf("JWT issuer is required by Istio RequestAuthentication")
+	}
+
+	rule := &istiov1beta1.JWTRule{
+		Issuer:  jwt.Issuer,
+		JwksURI: jwt.JwksURI,
+		Jwks:    jwt.Jwks,
+	}
+	if jwt.JwtHeader != "" {
+		rule.FromHeaders = []*istiov1beta1.JWTHeader{{Name: jwt.JwtHeader, Prefix: jwt.JwtHeaderPrefix}}
+	}
+	desired := &istiov1beta1.RequestAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            b.Name,
+			Namespace:       sub.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
+		},
+		Spec: istiov1beta1.RequestAuthenticationSpec{
+			Selector: workloadSelector(sub),
+			JWTRules: []*istiov1beta1.JWTRule{rule},
+		},
+	}
+
+	existing, err := r.istioauthnLister.RequestAuthentications(desired.Namespace).Get(desired.Name)
+	if apierrs.IsNotFound(err) {
+		existing, err = r.istioClientSet.SecurityV1beta1().RequestAuthentications(desired.Namespace).Create(desired)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create Istio RequestAuthentication: %w", err)
+		}
+		b.Status.MarkRequestAuthenticationReady()
+		return existing, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to get Istio RequestAuthentication: %w", err)
+	}
+
+	if !metav1.IsControlledBy(existing, b) {
+		return nil, fmt.Errorf("Istio RequestAuthentication %q is not owned by the binding", existing.Name)
+	}
+	if existing.DeletionTimestamp != nil {
+		return nil, fmt.Errorf("Istio RequestAuthentication %q is being deleted", existing.Name)
+	}
+
+	// Istio doesn't default the spec, so any difference is a drift.
+	if !equality.Semantic.DeepEqual(desired.Spec, existing.Spec) {
+		logging.FromContext(ctx).Info("Istio RequestAuthentication drifted", zap.String("name", existing.Name))
+		// Don't modify the informers copy.
+		cp := existing.DeepCopy()
+		cp.Spec = desired.Spec
+		existing, err = r.istioClientSet.SecurityV1beta1().RequestAuthentications(desired.Namespace).Update(cp)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to update Istio RequestAuthentication: %w", err)
+		}
+	}
+
+	b.Status.MarkRequestAuthenticationReady()
+	return existing, nil
+}
+
+func (r *Reconciler) deleteRequestAuthentication(b *v1alpha2.HTTPPolicyBinding, namespace string) error {
+	existing, err := r.istioauthnLister.RequestAuthentications(namespace).Get(b.Name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Failed to get Istio RequestAuthentication: %w", err)
+	}
+	if !metav1.IsControlledBy(existing, b) {
+		return nil
+	}
+	if err := r.istioClientSet.SecurityV1beta1().RequestAuthentications(namespace).Delete(existing.Name, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete Istio RequestAuthentication: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package istiobinding
+
+import (
+	istiov1beta1 "github.com/yolocs/knative-policy-binding/pkg/apis/istio/security/v1beta1"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/httppolicy"
+)
+
+// RequestAuthentication verifies JWT on all paths, it has no equivalent of
+// the JWT trigger rules. Like with the OPA binding, only JWT triggered
+// requests can match principals or claims, so the trigger rules are folded
+// into the operations of the rules that need a JWT. Invalid JWT are still
+// rejected by Istio on every path.
+
+// requiresJWT tells if the rule can only match requests with a JWT.
+func requiresJWT(r *v1alpha2.RuleSpec) bool {
+	if len(r.Auth.Principals) > 0 {
+		return true
+	}
+	for _, cl := range r.Auth.Claims {
+		if len(cl.Values) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// triggeredOperations narrows the operations down to the requests the JWT
+// trigger rules apply to. It returns false if no request is left.
+func triggeredOperations(ops []*istiov1beta1.Operation, triggers []v1alpha2.TriggerRule) ([]*istiov1beta1.Operation, bool) {
+	if len(triggers) == 0 {
+		return ops, true
+	}
+	if len(ops) == 0 {
+		ops = []*istiov1beta1.Operation{{}}
+	}
+
+	var ret []*istiov1beta1.Operation
+	for _, op := range ops {
+		for _, t := range triggers {
+			top := op.DeepCopy()
+			top.NotPaths = append(top.NotPaths, t.ExcludePaths...)
+			if len(t.IncludePaths) > 0 {
+				if len(op.Paths) == 0 {
+					top.Paths = t.IncludePaths
+				} else if top.Paths, _ = httppolicy.IntersectGlobs(op.Paths, t.IncludePaths); len(top.Paths) == 0 {
+					continue
+				}
+			}
+			ret = append(ret, top)
+		}
+	}
+	return ret, len(ret) > 0
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+
+	security "github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha1"
+	authbindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha1/authorizablebinding"
+	openpolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha1/openpolicy"
+	policybindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha1/policybinding"
+	httppolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicy"
+	httpbindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicybinding"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler"
+)
+
+const (
+	authbindingReconcilerName   = "AuthorizableBindingMigration"
+	policybindingReconcilerName = "PolicyBindingMigration"
+	controllerAgentName         = "migration-controller"
+)
+
+// NewAuthorizableBindingController initializes the controller migrating the
+// v1alpha1 AuthorizableBindings.
+func NewAuthorizableBindingController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+
+	authbindingInformer := authbindinginformer.Get(ctx)
+
+	r := &AuthorizableBindingReconciler{
+		migrator:          newMigrator(ctx, cmw),
+		authbindingLister: authbindingInformer.Lister(),
+	}
+	impl := controller.NewImpl(r, r.Logger, authbindingReconcilerName)
+
+	r.Logger.Info("Setting up event handlers")
+
+	authbindingInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	return impl
+}
+
+// NewPolicyBindingController initializes the controller migrating the
+// v1alpha1 PolicyBindings which are not created by AuthorizableBindings.
+func NewPolicyBindingController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+
+	policybindingInformer := policybindinginformer.Get(ctx)
+
+	r := &PolicyBindingReconciler{
+		migrator:            newMigrator(ctx, cmw),
+		policybindingLister: policybindingInformer.Lister(),
+	}
+	impl := controller.NewImpl(r, r.Logger, policybindingReconcilerName)
+
+	r.Logger.Info("Setting up event handlers")
+
+	policybindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			return !controller.Filter(security.SchemeGroupVersion.WithKind("AuthorizableBinding"))(obj)
+		},
+		Handler: controller.HandleAll(impl.Enqueue),
+	})
+
+	return impl
+}
+
+func newMigrator(ctx context.Context, cmw configmap.Watcher) *migrator {
+	return &migrator{
+		Base:              reconciler.NewBase(ctx, controllerAgentName, cmw),
+		openpolicyLister:  openpolicyinformer.Get(ctx).Lister(),
+		httppolicyLister:  httppolicyinformer.Get(ctx).Lister(),
+		httpbindingLister: httpbindinginformer.Get(ctx).Lister(),
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	policylisters "github.com/yolocs/knative-policy-binding/pkg/client/listers/security/v1alpha1"
+	httppolicylisters "github.com/yolocs/knative-policy-binding/pkg/client/listers/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/opa"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler"
+)
+
+const (
+	// MigratedFromAnnotationKey records the v1alpha1 object an
+	// HTTPPolicyBinding or HTTPPolicy is migrated from, as "<kind>/<name>".
+	MigratedFromAnnotationKey = security.GroupName + "/migratedFrom"
+
+	// Name of the corev1.Events emitted from the migration process.
+	bindingMigrated           = "BindingMigrated"
+	bindingMigrationConflict  = "BindingMigrationConflict"
+	bindingMigrationNotViable = "BindingMigrationNotViable"
+)
+
+// errNotViable marks the reasons a binding can't be migrated. They don't go
+// away by retrying.
+var errNotViable = errors.New("no v1alpha2 equivalent")
+
+// migrator creates the HTTPPolicyBindings equivalent to the v1alpha1
+// bindings. The opa class injects the same agent as v1alpha1, replacing the
+// one injected by the v1alpha1 bindings. An HTTPPolicyBinding is only created
+// once, it's never updated, so it can be edited once migrated. It's created
+// again if it's deleted while the v1alpha1 binding exists.
+type migrator struct {
+	*reconciler.Base
+
+	openpolicyLister  policylisters.OpenPolicyLister
+	httppolicyLister  httppolicylisters.HTTPPolicyLister
+	httpbindingLister httppolicylisters.HTTPPolicyBindingLister
+}
+
+// migrate creates the HTTPPolicyBinding with the desired spec migrated from
+// the source binding, unless a binding with the same name exists.
+func (m *migrator) migrate(ctx context.Context, kind string, src kmeta.Accessor, desired func() (*v1alpha2.HTTPPolicyBindingSpec, error)) error {
+	from := kind + "/" + src.GetName()
+
+	existing, err := m.httpbindingLister.HTTPPolicyBindings(src.GetNamespace()).Get(src.GetName())
+	if err == nil {
+		if existing.Annotations[MigratedFromAnnotationKey] != from {
+			m.Recorder.Eventf(src, corev1.EventTypeWarning, bindingMigrationConflict, "HTTPPolicyBinding %q already exists", src.GetName())
+		}
+		return nil
+	} else if !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	spec, err := desired()
+	if errors.Is(err, errNotViable) {
+		logging.FromContext(ctx).Infof("Binding %s can't be migrated: %v", from, err)
+		m.Recorder.Eventf(src, corev1.EventTypeWarning, bindingMigrationNotViable, "Binding can't be migrated: %v", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	b := &v1alpha2.HTTPPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      src.GetName(),
+			Namespace: src.GetNamespace(),
+			Annotations: map[string]string{
+				security.BindingClassAnnotationKey: security.OPABindingClass,
+				MigratedFromAnnotationKey:          from,
+			},
+		},
+		Spec: *spec,
+	}
+	if _, err := m.SecurityClientSet.SecurityV1alpha2().HTTPPolicyBindings(b.Namespace).Create(b); err != nil {
+		return fmt.Errorf("Failed to create HTTPPolicyBinding: %w", err)
+	}
+	m.Recorder.Eventf(src, corev1.EventTypeNormal, bindingMigrated, "Migrated to HTTPPolicyBinding %q", b.Name)
+	return nil
+}
+
+// migratePolicy returns the reference to the v1alpha2 policy equivalent to
+// the v1alpha1 one. EventPolicies are converted by the webhook, OpenPolicies
+// are migrated to HTTPPolicies with their rule as Rego.
+func (m *migrator) migratePolicy(namespace string, ref *corev1.ObjectReference) (*corev1.ObjectReference, error) {
+	if ref == nil {
+		return nil, fmt.Errorf("the binding has no policy: %w", errNotViable)
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil || gv.Group != security.GroupName {
+		return nil, fmt.Errorf("policy %s %q: %w", ref.Kind, ref.Name, errNotViable)
+	}
+	if ref.Namespace != "" && ref.Namespace != namespace {
+		return nil, fmt.Errorf("policy %q is in another namespace: %w", ref.Name, errNotViable)
+	}
+
+	switch ref.Kind {
+	case "EventPolicy":
+		return &corev1.ObjectReference{
+			APIVersion: v1alpha2.SchemeGroupVersion.String(),
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+		}, nil
+	case "OpenPolicy":
+		return m.migrateOpenPolicy(namespace, ref.Name)
+	default:
+		return nil, fmt.Errorf("policy %s %q: %w", ref.Kind, ref.Name, errNotViable)
+	}
+}
+
+// migrateOpenPolicy creates the HTTPPolicy with the rule of the OpenPolicy as
+// Rego, unless it exists. The "allow" rule of the OpenPolicy decides through
+// "allowed" in the policy package, under the same DENY rules and JWT
+// verification as the other HTTPPolicies.
+func (m *migrator) migrateOpenPolicy(namespace, name string) (*corev1.ObjectReference, error) {
+	ref := &corev1.ObjectReference{
+		APIVersion: v1alpha2.SchemeGroupVersion.String(),
+		Kind:       "HTTPPolicy",
+		Name:       name,
+	}
+	from := "OpenPolicy/" + name
+
+	existing, err := m.httppolicyLister.HTTPPolicies(namespace).Get(name)
+	if err == nil {
+		if existing.Annotations[MigratedFromAnnotationKey] != from {
+			return nil, fmt.Errorf("HTTPPolicy %q already exists: %w", name, errNotViable)
+		}
+		return ref, nil
+	} else if !apierrs.IsNotFound(err) {
+		return nil, err
+	}
+
+	op, err := m.openpolicyLister.OpenPolicies(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil, fmt.Errorf("OpenPolicy %q not found: %w", name, errNotViable)
+	} else if err != nil {
+		return nil, err
+	}
+	// The v1alpha2 agents don't send the request bodies to the decider.
+	if op.Spec.CheckPayload {
+		return nil, fmt.Errorf("OpenPolicy %q checks the payload: %w", name, errNotViable)
+	}
+	if err := opa.CheckRego(op.Spec.Rule); err != nil {
+		return nil, fmt.Errorf("OpenPolicy %q rule: %v: %w", name, err, errNotViable)
+	}
+
+	p := &v1alpha2.HTTPPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				MigratedFromAnnotationKey: from,
+			},
+		},
+		Spec: v1alpha2.HTTPPolicySpec{
+			Rego: op.Spec.Rule,
+		},
+	}
+	// The other controller may have created it for another binding.
+	if _, err := m.SecurityClientSet.SecurityV1alpha2().HTTPPolicies(namespace).Create(p); err != nil && !apierrs.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("Failed to create HTTPPolicy: %w", err)
+	}
+	return ref, nil
+}
+
+// AuthorizableBindingReconciler migrates the AuthorizableBindings.
+type AuthorizableBindingReconciler struct {
+	*migrator
+
+	authbindingLister policylisters.AuthorizableBindingLister
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*AuthorizableBindingReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *AuthorizableBindingReconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+
+	b, err := r.authbindingLister.AuthorizableBindings(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if b.DeletionTimestamp != nil {
+		return nil
+	}
+
+	return r.migrate(ctx, b.GetGroupVersionKind().Kind, b, func() (*v1alpha2.HTTPPolicyBindingSpec, error) {
+		if b.Spec.Subject == nil {
+			return nil, fmt.Errorf("the binding has no subject: %w", errNotViable)
+		}
+		policy, err := r.migratePolicy(b.Namespace, b.Spec.Policy)
+		if err != nil {
+			return nil, err
+		}
+		return &v1alpha2.HTTPPolicyBindingSpec{
+			Subject: b.Spec.Subject.DeepCopy(),
+			Policy:  policy,
+		}, nil
+	})
+}
+
+// PolicyBindingReconciler migrates the PolicyBindings which are not created
+// by AuthorizableBindings.
+type PolicyBindingReconciler struct {
+	*migrator
+
+	policybindingLister policylisters.PolicyBindingLister
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*PolicyBindingReconciler)(nil)
+
+// Reconcile implements controller.Reconciler
+func (r *PolicyBindingReconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("invalid resource key: %s", key)
+		return nil
+	}
+
+	b, err := r.policybindingLister.PolicyBindings(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if b.DeletionTimestamp != nil {
+		return nil
+	}
+
+	return r.migrate(ctx, b.GetGroupVersionKind().Kind, b, func() (*v1alpha2.HTTPPolicyBindingSpec, error) {
+		subject, err := migrateSubject(b.Namespace, &b.Spec.Subject)
+		if err != nil {
+			return nil, err
+		}
+		policy, err := r.migratePolicy(b.Namespace, b.Spec.Policy)
+		if err != nil {
+			return nil, err
+		}
+		return &v1alpha2.HTTPPolicyBindingSpec{
+			Subject: subject,
+			Policy:  policy,
+		}, nil
+	})
+}
+
+// migrateSubject returns the subject of the HTTPPolicyBinding equivalent to
+// the podspecable subject. The workloads selected by labels would include
+// other kinds than the subject's, so only the named subjects are migrated.
+func migrateSubject(namespace string, ref *tracker.Reference) (*corev1.ObjectReference, error) {
+	if ref.Name == "" {
+		return nil, fmt.Errorf("subject %s selected by labels: %w", ref.Kind, errNotViable)
+	}
+	if ref.Namespace != "" && ref.Namespace != namespace {
+		return nil, fmt.Errorf("subject %q is in another namespace: %w", ref.Name, errNotViable)
+	}
+	return &corev1.ObjectReference{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Namespace:  namespace,
+		Name:       ref.Name,
+	}, nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opabinding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+
+	"github.com/yolocs/knative-policy-binding/pkg/agent"
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler/internal/resolver"
+)
+
+const (
+	agentPort = 8090
+	// policyVolumeName is the volume of the agent mounting the configmap of
+	// the binding's policy, named after the binding.
+	policyVolumeName = "open-policy"
+
+	agentProbeTimeout = time.Second
+	// agentProbeBudget bounds how long a reconcile spends probing the
+	// agents, and maxConcurrentAgentProbes how many are probed at once.
+	// Agents not probed in time count as not converged yet.
+	agentProbeBudget         = 3 * time.Second
+	maxConcurrentAgentProbes = 10
+	// convergenceRecheckDelay is how long to wait before probing the agents
+	// again when some of them are still on an old policy revision.
+	convergenceRecheckDelay = 5 * time.Second
+)
+
+func isHotUpdate(b *v1alpha2.HTTPPolicyBinding) bool {
+	return b.GetAnnotations()[policyUpdateAnnotationKey] == policyUpdateHot
+}
+
+// reconcileAgentsConvergence reports whether the agents of all the subject
+// pods enforce the binding's policy revision. The binding is requeued until
+// they do. Without agents, e.g. when the workload is scaled to zero, it is
+// only reconciled again when an agent pod changes.
+func (r *Reconciler) reconcileAgentsConvergence(ctx context.Context, b *v1alpha2.HTTPPolicyBinding, sub *resolver.Subject) {
+	converged, total, err := r.probeAgents(ctx, sub, b.Name, b.Status.PolicyRevision)
+	if err != nil {
+		logging.FromContext(ctx).Error("Problem probing policy agents", zap.Error(err))
+		b.Status.MarkAgentsConverging("AgentProbeFailure", "%v", err)
+		r.enqueueAfter(b, convergenceRecheckDelay)
+		return
+	}
+	if total == 0 {
+		// Nothing was checked, the pods may not be created or injected yet.
+		b.Status.MarkAgentsConverging("NoAgents", "No running subject pod runs the policy agent")
+		return
+	}
+	if converged < total {
+		b.Status.MarkAgentsConverging("AgentsUpdating", "%d of %d agents enforce policy revision %s", converged, total, b.Status.PolicyRevision)
+		r.enqueueAfter(b, convergenceRecheckDelay)
+		return
+	}
+	b.Status.MarkAgentsConverged()
+}
+
+// probeAgents asks the agent of every running subject pod mounting the policy
+// configmap for its policy revision and returns how many of them enforce the
+// given revision. The probes are bounded by agentProbeBudget.
+func (r *Reconciler) probeAgents(ctx context.Context, sub *resolver.Subject, policyConfigMap, revision string) (int, int, error) {
+	selector, err := metav1.LabelSelectorAsSelector(sub.Selector)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid subject selector: %w", err)
+	}
+	pods, err := r.podLister.Pods(sub.Namespace).List(selector)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentProbeBudget)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, maxConcurrentAgentProbes)
+		converged int
+		total     int
+	)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		// Pods selected by namespace-wide bindings may run another binding's
+		// agent, or none.
+		if !mountsConfigMap(pod, policyConfigMap) {
+			continue
+		}
+		total++
+		wg.Add(1)
+		go func(pod *corev1.Pod) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			got, err := r.agentRevision(ctx, pod.Status.PodIP)
+			if err != nil {
+				logging.FromContext(ctx).Debug("Failed to get agent policy revision", zap.String("pod", pod.Name), zap.Error(err))
+				return
+			}
+			if got == revision {
+				mu.Lock()
+				converged++
+				mu.Unlock()
+			}
+		}(pod)
+	}
+	wg.Wait()
+	return converged, total, nil
+}
+
+// enqueueBindingOfPod enqueues the binding whose policy the agent of the pod
+// enforces, if any.
+func enqueueBindingOfPod(enqueue func(types.NamespacedName)) func(interface{}) {
+	return func(obj interface{}) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.Name == policyVolumeName && v.ConfigMap != nil {
+				enqueue(types.NamespacedName{Namespace: pod.Namespace, Name: v.ConfigMap.Name})
+				return
+			}
+		}
+	}
+}
+
+func mountsConfigMap(pod *corev1.Pod, name string) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.ConfigMap != nil && v.ConfigMap.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reconciler) agentRevision(ctx context.Context, podIP string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+net.JoinHostPort(podIP, strconv.Itoa(agentPort)), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.agentClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+	var status agent.PolicyStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return "", fmt.Errorf("failed to decode agent policy status: %w", err)
+	}
+	return status.Revision, nil
+}
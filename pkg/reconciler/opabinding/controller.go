@@ -19,6 +19,7 @@ package opabinding
 import (
 	"context"
 	"log"
+	"net/http"
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/tools/cache"
@@ -28,13 +29,17 @@ import (
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+	clusterpolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/clusterhttppolicy"
+	eventpolicyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/eventpolicy"
 	policyinformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicy"
 	bindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/httppolicybinding"
 	policypsbindinginformer "github.com/yolocs/knative-policy-binding/pkg/client/injection/informers/security/v1alpha2/policypodspecablebinding"
-	bindingreconciler "github.com/yolocs/knative-policy-binding/pkg/client/injection/reconciler/security/v1alpha2/httppolicybinding"
+	"github.com/yolocs/knative-policy-binding/pkg/httppolicy"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler"
+	"github.com/yolocs/knative-policy-binding/pkg/reconciler/httppolicybinding"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/internal/resolver"
 	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
+	podinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/pod"
 )
 
 const (
@@ -47,12 +52,13 @@ type config struct {
 	AgentImage string `envconfig:"AGENT_IMAGE" required:"true"`
 }
 
-// NewController initializes the controller and is called by the generated code
-// Registers event handlers to enqueue events
-func NewController(
+// NewClassReconciler initializes the reconciler of the opa binding class and
+// registers event handlers to enqueue bindings with impl.
+func NewClassReconciler(
 	ctx context.Context,
 	cmw configmap.Watcher,
-) *controller.Impl {
+	impl *controller.Impl,
+) httppolicybinding.ClassReconciler {
 
 	var env config
 	if err := envconfig.Process("", &env); err != nil {
@@ -61,32 +67,51 @@ func NewController(
 
 	bindingInformer := bindinginformer.Get(ctx)
 	policyInformer := policyinformer.Get(ctx)
+	clusterPolicyInformer := clusterpolicyinformer.Get(ctx)
+	eventPolicyInformer := eventpolicyinformer.Get(ctx)
 	configmapInformer := configmapinformer.Get(ctx)
 	psbindingInformer := policypsbindinginformer.Get(ctx)
+	podInformer := podinformer.Get(ctx)
 
 	r := &Reconciler{
 		Base:                reconciler.NewBase(ctx, controllerAgentName, cmw),
 		policybindingLister: bindingInformer.Lister(),
 		policyLister:        policyInformer.Lister(),
+		clusterPolicyLister: clusterPolicyInformer.Lister(),
+		eventPolicyLister:   eventPolicyInformer.Lister(),
 		psbindingLister:     psbindingInformer.Lister(),
 		configmapLister:     configmapInformer.Lister(),
+		podLister:           podInformer.Lister(),
 		agentImage:          env.AgentImage,
+		agentClient:         &http.Client{Timeout: agentProbeTimeout},
+		jwks:                newJWKSCache(),
 	}
-	impl := bindingreconciler.NewImpl(ctx, r)
+	r.enqueueAfter = impl.EnqueueAfter
 
 	r.Logger.Info("Setting up event handlers")
 
-	bindingInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
-
 	psbindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.Filter(v1alpha2.SchemeGroupVersion.WithKind("HTTPPolicyBinding")),
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	configmapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.Filter(v1alpha2.SchemeGroupVersion.WithKind("HTTPPolicyBinding")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	bindingInformer.Informer().AddEventHandler(controller.HandleAll(r.enqueueBaselined(impl.EnqueueKey)))
+
+	// Pods starting or stopping to run an agent change the convergence of
+	// the hot updated bindings.
+	podInformer.Informer().AddEventHandler(controller.HandleAll(enqueueBindingOfPod(impl.EnqueueKey)))
+
 	r.subjectResolver = resolver.NewSubjectResolver(ctx, impl.EnqueueKey)
 	r.policyTracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
 
-	policyInformer.Informer().AddEventHandler(controller.HandleAll(r.policyTracker.OnChanged))
+	policyInformer.Informer().AddEventHandler(controller.HandleAll(httppolicy.OnChanged(r.policyTracker)))
+	clusterPolicyInformer.Informer().AddEventHandler(controller.HandleAll(httppolicy.OnChanged(r.policyTracker)))
+	eventPolicyInformer.Informer().AddEventHandler(controller.HandleAll(httppolicy.OnChanged(r.policyTracker)))
 
-	return impl
+	return r
 }
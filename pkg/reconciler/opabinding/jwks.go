@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opabinding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
+)
+
+const (
+	// jwksRefreshInterval is how often the JWKS from a URI is refreshed, so
+	// that rotated keys reach the agents.
+	jwksRefreshInterval = 10 * time.Minute
+	jwksFetchTimeout    = 5 * time.Second
+	maxJWKSBytes        = 1 << 20
+)
+
+// resolveJWKS returns the JWKS to verify JWT with. The agents don't fetch the
+// JWKS themselves, so the JWKS from a URI is embedded into the policy and the
+// binding is requeued to pick up key rotations.
+func (r *Reconciler) resolveJWKS(ctx context.Context, b *v1alpha2.HTTPPolicyBinding, jwt *v1alpha2.JWTSpec) (string, error) {
+	if jwt.JwksURI == "" {
+		return jwt.Jwks, nil
+	}
+	jwks, err := r.jwks.get(ctx, jwt.JwksURI)
+	if err != nil {
+		return "", err
+	}
+	r.enqueueAfter(b, jwksRefreshInterval)
+	return jwks, nil
+}
+
+type jwksEntry struct {
+	jwks    string
+	fetched time.Time
+}
+
+// jwksFetch is a fetch of the JWKS from a URI shared by the concurrent gets.
+// done is closed once jwks and err are set.
+type jwksFetch struct {
+	done chan struct{}
+	jwks string
+	err  error
+}
+
+// jwksCache caches the JWKS fetched from URIs.
+type jwksCache struct {
+	client *http.Client
+
+	mu       sync.Mutex
+	entries  map[string]jwksEntry
+	inflight map[string]*jwksFetch
+}
+
+func newJWKSCache() *jwksCache {
+	return &jwksCache{
+		client:   &http.Client{Timeout: jwksFetchTimeout},
+		entries:  make(map[string]jwksEntry),
+		inflight: make(map[string]*jwksFetch),
+	}
+}
+
+// get returns the JWKS from the URI. If refreshing the JWKS fails, the last
+// fetched one is kept. The JWKS is fetched without holding the lock, once for
+// all the concurrent gets of the same URI.
+func (c *jwksCache) get(ctx context.Context, uri string) (string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[uri]
+	if ok && time.Since(e.fetched) < jwksRefreshInterval {
+		c.mu.Unlock()
+		return e.jwks, nil
+	}
+	f, fetching := c.inflight[uri]
+	if !fetching {
+		f = &jwksFetch{done: make(chan struct{})}
+		c.inflight[uri] = f
+	}
+	c.mu.Unlock()
+
+	if fetching {
+		<-f.done
+	} else {
+		f.jwks, f.err = c.fetch(uri)
+		c.mu.Lock()
+		if f.err == nil {
+			c.entries[uri] = jwksEntry{jwks: f.jwks, fetched: time.Now()}
+		}
+		delete(c.inflight, uri)
+		c.mu.Unlock()
+		close(f.done)
+	}
+
+	if f.err != nil {
+		if ok {
+			logging.FromContext(ctx).Warn("Failed to refresh JWKS, keeping the previous one", zap.String("uri", uri), zap.Error(f.err))
+			return e.jwks, nil
+		}
+		return "", f.err
+	}
+	return f.jwks, nil
+}
+
+func (c *jwksCache) fetch(uri string) (string, error) {
+	resp, err := c.client.Get(uri)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil || len(set.Keys) == 0 {
+		return "", fmt.Errorf("invalid JWKS from %s", uri)
+	}
+	return string(b), nil
+}
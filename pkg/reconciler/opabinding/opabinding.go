@@ -19,18 +19,29 @@ package opabinding
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/yolocs/knative-policy-binding/pkg/apis/security"
 	"github.com/yolocs/knative-policy-binding/pkg/apis/security/v1alpha2"
 	securitylisters "github.com/yolocs/knative-policy-binding/pkg/client/listers/security/v1alpha2"
+	"github.com/yolocs/knative-policy-binding/pkg/httppolicy"
 	"github.com/yolocs/knative-policy-binding/pkg/opa"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler"
 	"github.com/yolocs/knative-policy-binding/pkg/reconciler/internal/resolver"
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	duckv1alpha1 "knative.dev/pkg/apis/duck/v1alpha1"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
@@ -39,10 +50,18 @@ import (
 )
 
 const (
-	bindingReconcileError     = "HTTPPolicyBindingReconcileError"
-	bindingReconciled         = "HTTPPolicyBindingReconciled"
-	bindingClassAnnotationKey = "security.knative.dev/binding.class"
-	bindingClass              = "opa"
+	bindingReconcileError = "HTTPPolicyBindingReconcileError"
+	bindingReconciled     = "HTTPPolicyBindingReconciled"
+
+	// proxiedUserPort is the port the user container is moved to when the
+	// agent proxy takes over its port.
+	proxiedUserPort = 8091
+
+	// policyUpdateAnnotationKey selects how policy changes reach the agents.
+	// With "hot" the agents reload the policy in place and the workload is
+	// left untouched, otherwise the pods are rolled on every policy change.
+	policyUpdateAnnotationKey = "security.knative.dev/policy.update"
+	policyUpdateHot           = "hot"
 )
 
 type Reconciler struct {
@@ -50,76 +69,226 @@ type Reconciler struct {
 
 	policybindingLister securitylisters.HTTPPolicyBindingLister
 	policyLister        securitylisters.HTTPPolicyLister
+	clusterPolicyLister securitylisters.ClusterHTTPPolicyLister
+	eventPolicyLister   securitylisters.EventPolicyLister
 	psbindingLister     securitylisters.PolicyPodspecableBindingLister
 	configmapLister     corev1listers.ConfigMapLister
+	podLister           corev1listers.PodLister
 
 	subjectResolver *resolver.SubjectResolver
 	policyTracker   tracker.Interface
 
 	agentImage string
+
+	// agentClient is used to probe the agents' policy revision.
+	agentClient  *http.Client
+	jwks         *jwksCache
+	enqueueAfter func(interface{}, time.Duration)
 }
 
 func (r *Reconciler) ReconcileKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) pkgreconciler.Event {
-	if b.GetAnnotations()[bindingClassAnnotationKey] != bindingClass {
-		logging.FromContext(ctx).Info("Not reconciling binding, cause it's not mine", zap.String("HTTPPolicyBinding", b.Name))
-		return nil
-	}
-
 	logging.FromContext(ctx).Debug("Reconciling", zap.Any("HTTPPolicyBinding", b))
 	b.Status.InitializeConditions()
 	b.Status.ObservedGeneration = b.Generation
 
-	sub, err := r.subjectResolver.ResolveFromRef(b.Spec.Subject, b)
+	var sub *resolver.Subject
+	if b.Spec.IsNamespaceWide() {
+		sub = resolver.ResolveWorkloads(b.Namespace, b.Spec.Workloads.Selector)
+	} else {
+		var err error
+		sub, err = r.subjectResolver.ResolveFromRef(b.Spec.Subject, b)
+		if err != nil {
+			logging.FromContext(ctx).Error("Problem resolving binding subject", zap.Error(err))
+			b.Status.MarkBindingSubjectResolvingFaiulre("SubjectResolvingFailure", "%v", err)
+			return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+		}
+		if sub.Selector == nil || len(sub.Selector.MatchLabels) == 0 {
+			logging.FromContext(ctx).Error("Resolved binding target is not a label selector")
+			b.Status.MarkBindingSubjectResolvingFaiulre("SubjectNotLabelSelector", "Istio AuthorizationPolicy can only select workload by labels")
+			return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+		}
+	}
+	b.Status.MarkBindingSubjectResolved(sub.Reference())
+
+	policies, spec, err := httppolicy.Resolve(r.policyLister, r.clusterPolicyLister, r.eventPolicyLister, r.policyTracker, b)
 	if err != nil {
-		logging.FromContext(ctx).Error("Problem resolving binding subject", zap.Error(err))
-		b.Status.MarkBindingSubjectResolvingFaiulre("SubjectResolvingFailure", "%v", err)
-		return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+		logging.FromContext(ctx).Error("Problem resolving policies", zap.Error(err))
+		b.Status.MarkBindingUnavailable("GetPolicyFailure", err.Error())
+		return fmt.Errorf("Failed to resolve the referencing policies: %w", err)
 	}
-	if sub.Selector == nil || len(sub.Selector.MatchLabels) == 0 {
-		logging.FromContext(ctx).Error("Resolved binding target is not a label selector")
-		b.Status.MarkBindingSubjectResolvingFaiulre("SubjectNotLabelSelector", "Istio AuthorizationPolicy can only select workload by labels")
-		return fmt.Errorf("Failed to reconcile HTTP policy binding: %w", err)
+	if !b.Spec.IsNamespaceWide() {
+		baselines, specs, err := r.resolveBaselines(b, sub)
+		if err == nil && len(specs) > 0 {
+			spec, err = httppolicy.Compose(v1alpha2.PolicyCompositionAnyOf, append([]*v1alpha2.HTTPPolicySpec{spec}, specs...))
+			policies = append(policies, baselines...)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Problem composing namespace-wide bindings", zap.Error(err))
+			b.Status.MarkBindingUnavailable("BaselineFailure", err.Error())
+			return fmt.Errorf("Failed to compose namespace-wide bindings: %w", err)
+		}
 	}
-	b.Status.MarkBindingSubjectResolved(sub)
+	b.Status.MarkPoliciesResolved(policies)
 
-	p, err := r.policyLister.HTTPPolicies(b.Spec.Policy.Namespace).Get(b.Spec.Policy.Name)
+	jwks, err := r.resolveJWKS(ctx, b, &spec.JWT)
 	if err != nil {
-		logging.FromContext(ctx).Error("Problem getting policy", zap.Error(err))
-		b.Status.MarkBindingUnavailable("GetPolicyFailure", err.Error())
-		return fmt.Errorf("Failed to get the referencing policy: %w", err)
+		logging.FromContext(ctx).Error("Problem resolving JWKS", zap.Error(err))
+		b.Status.MarkBindingUnavailable("JWKSFailure", err.Error())
+		return fmt.Errorf("Failed to resolve JWKS: %w", err)
 	}
-	r.policyTracker.Track(*b.Spec.Policy, b)
 
-	if err := r.reconcileConfigMap(ctx, b, p); err != nil {
+	m, err := policyToRego(spec, jwks)
+	if err != nil {
+		logging.FromContext(ctx).Error("Problem merging Rego rules", zap.Error(err))
+		b.Status.MarkBindingUnavailable("RegoFailure", err.Error())
+		return fmt.Errorf("Failed to merge Rego rules: %w", err)
+	}
+	if err := r.reconcileConfigMap(ctx, b, m); err != nil {
 		logging.FromContext(ctx).Error("Problem reconciling OPA policy configmap", zap.Error(err))
 		b.Status.MarkBindingUnavailable("ConfigMapFailure", err.Error())
 		return fmt.Errorf("Failed to reconcile OPA policy configmap: %w", err)
 	}
 
-	pb, err := r.reconcilePodspecableBinding(ctx, sub, p, b)
+	pbs, err := r.reconcilePodspecableBindings(ctx, sub, policies, b)
 	if err != nil {
 		logging.FromContext(ctx).Error("Problem reconciling policy podspecable binding", zap.Error(err))
 		b.Status.MarkBindingUnavailable("PolicyPodspecableBindingFailure", err.Error())
 		return fmt.Errorf("Failed to reconcile PolicyPodspecablebinding: %w", err)
 	}
 
-	if pb.Status.IsReady() {
-		b.Status.MarkBindingAvailable()
-	} else {
+	b.Status.PolicyRevision = opa.Revision(m)
+
+	// The subject is resolved again when its workloads change.
+	if len(pbs) == 0 {
+		b.Status.MarkBindingUnavailable("NoWorkloads", fmt.Sprintf("No workloads run the subject in %s", sub.Namespace))
+		b.Status.ClearAgentsConverged()
+		return nil
+	}
+
+	// The binding is reconciled again when the PolicyPodspecableBindings
+	// become ready, e.g. once a conflict is gone.
+	for _, pb := range pbs {
+		if pb.Status.IsReady() {
+			continue
+		}
 		cond := pb.Status.GetTopLevelCondition()
 		if cond != nil {
 			b.Status.MarkBindingUnavailable(cond.Reason, cond.Message)
 		} else {
 			b.Status.MarkBindingUnavailable("PolicyPodspecableBindingNotReady", "")
 		}
+		b.Status.ClearAgentsConverged()
+		return nil
 	}
 
 	b.Status.MarkBindingAvailable()
+	if isHotUpdate(b) {
+		r.reconcileAgentsConvergence(ctx, b, sub)
+	} else {
+		b.Status.ClearAgentsConverged()
+	}
 	return nil
 }
 
-func (r *Reconciler) reconcileConfigMap(ctx context.Context, b *v1alpha2.HTTPPolicyBinding, p *v1alpha2.HTTPPolicy) pkgreconciler.Event {
-	m := policyToRego(&p.Spec)
+// resolveBaselines resolves the policies of the namespace-wide bindings of the
+// opa class selecting the pods of the subject. Their agents yield to the
+// binding's, so like with Istio AuthorizationPolicies, the binding allows the
+// requests their ALLOW rules allow, and their DENY rules apply.
+func (r *Reconciler) resolveBaselines(b *v1alpha2.HTTPPolicyBinding, sub *resolver.Subject) ([]kmeta.OwnerRefable, []*v1alpha2.HTTPPolicySpec, error) {
+	bindings, err := r.policybindingLister.HTTPPolicyBindings(b.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list bindings: %w", err)
+	}
+	var podLabels labels.Set
+	if sub.Selector != nil {
+		podLabels = sub.Selector.MatchLabels
+	}
+
+	var policies []kmeta.OwnerRefable
+	var specs []*v1alpha2.HTTPPolicySpec
+	for _, nb := range bindings {
+		if !isBaselineOf(nb, podLabels) {
+			continue
+		}
+		ps, spec, err := httppolicy.ResolveFor(r.policyLister, r.clusterPolicyLister, r.eventPolicyLister, r.policyTracker, b, nb)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve the policies of namespace-wide binding %s: %w", nb.Name, err)
+		}
+		policies = append(policies, ps...)
+		specs = append(specs, spec)
+	}
+	return policies, specs, nil
+}
+
+// isBaselineOf tells if the binding is a namespace-wide binding of the opa
+// class selecting the pods with the labels.
+func isBaselineOf(b *v1alpha2.HTTPPolicyBinding, podLabels labels.Set) bool {
+	if !b.Spec.IsNamespaceWide() || b.DeletionTimestamp != nil ||
+		b.GetAnnotations()[security.BindingClassAnnotationKey] != security.OPABindingClass {
+		return false
+	}
+	if b.Spec.Workloads.Selector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(b.Spec.Workloads.Selector)
+	return err == nil && selector.Matches(podLabels)
+}
+
+// enqueueBaselined enqueues the bindings of the namespace when a namespace-wide
+// binding of the opa class changes, as they compose it.
+func (r *Reconciler) enqueueBaselined(enqueue func(types.NamespacedName)) func(interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		nb, ok := obj.(*v1alpha2.HTTPPolicyBinding)
+		if !ok || !nb.Spec.IsNamespaceWide() || nb.GetAnnotations()[security.BindingClassAnnotationKey] != security.OPABindingClass {
+			return
+		}
+		bindings, err := r.policybindingLister.HTTPPolicyBindings(nb.Namespace).List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, b := range bindings {
+			if !b.Spec.IsNamespaceWide() {
+				enqueue(types.NamespacedName{Namespace: b.Namespace, Name: b.Name})
+			}
+		}
+	}
+}
+
+// CleanupKind implements httppolicybinding.ClassCleaner. The
+// PolicyPodspecableBindings are deleted first, so that their finalizers remove
+// the agents from the workloads, and the policy ConfigMap the agents mount
+// once they are gone.
+func (r *Reconciler) CleanupKind(ctx context.Context, b *v1alpha2.HTTPPolicyBinding) (bool, error) {
+	b.Status.ClearAgentsConverged()
+	b.Status.PolicyRevision = ""
+
+	pbs, err := r.ownedPodspecableBindings(b)
+	if err != nil {
+		return false, err
+	}
+	if len(pbs) > 0 {
+		return true, r.deleteStalePodspecableBindings(b, nil)
+	}
+
+	cm, err := r.configmapLister.ConfigMaps(b.Namespace).Get(b.Name)
+	if apierrs.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get configmap: %w", err)
+	}
+	if !metav1.IsControlledBy(cm, b) {
+		return false, nil
+	}
+	if err := r.KubeClientSet.CoreV1().ConfigMaps(cm.Namespace).Delete(cm.Name, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+		return true, fmt.Errorf("failed to delete configmap: %w", err)
+	}
+	return true, nil
+}
+
+func (r *Reconciler) reconcileConfigMap(ctx context.Context, b *v1alpha2.HTTPPolicyBinding, m string) pkgreconciler.Event {
 	desired := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            b.Name,
@@ -153,23 +322,81 @@ func (r *Reconciler) reconcileConfigMap(ctx context.Context, b *v1alpha2.HTTPPol
 	return nil
 }
 
-func (r *Reconciler) reconcilePodspecableBinding(
-	ctx context.Context, sub *tracker.Reference, p *v1alpha2.HTTPPolicy, b *v1alpha2.HTTPPolicyBinding) (*v1alpha2.PolicyPodspecableBinding, pkgreconciler.Event) {
-	desired := &v1alpha2.PolicyPodspecableBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            b.Name,
-			Namespace:       b.Namespace,
-			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
-			Annotations:     map[string]string{"security.knative.dev/policyGeneration": fmt.Sprintf("%d", p.ObjectMeta.Generation)},
-		},
-		Spec: v1alpha2.PolicyPodspecableBindingSpec{
-			BindingSpec: duckv1alpha1.BindingSpec{
-				Subject: *sub,
+// namespaceWorkloadKinds are the kinds of workloads namespace-wide bindings
+// inject the agent into. Knative Services run their pods with Deployments.
+var namespaceWorkloadKinds = []schema.GroupVersionKind{
+	appsv1.SchemeGroupVersion.WithKind("Deployment"),
+	appsv1.SchemeGroupVersion.WithKind("StatefulSet"),
+	appsv1.SchemeGroupVersion.WithKind("DaemonSet"),
+}
+
+// reconcilePodspecableBindings reconciles the PolicyPodspecableBindings
+// injecting the agent into the subject. A PolicyPodspecableBinding only
+// applies to one kind of workloads, so namespace-wide bindings need one for
+// every kind. They yield to the bindings of specific workloads. Subjects
+// running on several workloads get one for each of them.
+func (r *Reconciler) reconcilePodspecableBindings(
+	ctx context.Context, sub *resolver.Subject, policies []kmeta.OwnerRefable, b *v1alpha2.HTTPPolicyBinding) ([]*v1alpha2.PolicyPodspecableBinding, pkgreconciler.Event) {
+	annotations := map[string]string{}
+	if !isHotUpdate(b) {
+		annotations[v1alpha2.PolicyGenerationAnnotationKey] = policyGenerations(policies)
+	}
+	subjects := map[string]tracker.Reference{}
+	switch {
+	case b.Spec.IsNamespaceWide():
+		annotations[v1alpha2.FallbackAnnotationKey] = "true"
+		for _, gvk := range namespaceWorkloadKinds {
+			apiVersion, kind := gvk.ToAPIVersionAndKind()
+			subjects[b.Name+"-"+strings.ToLower(gvk.Kind)] = tracker.Reference{
+				APIVersion: apiVersion,
+				Kind:       kind,
+				Namespace:  sub.Namespace,
+				Selector:   sub.Selector.DeepCopy(),
+			}
+		}
+	case len(sub.Workloads) == 1:
+		subjects[b.Name] = sub.Workloads[0]
+	default:
+		// E.g. the Deployments and StatefulSets behind a Service.
+		for _, w := range sub.Workloads {
+			subjects[b.Name+"-"+strings.ToLower(w.Kind)+"-"+w.Name] = w
+		}
+	}
+
+	var pbs []*v1alpha2.PolicyPodspecableBinding
+	for name, s := range subjects {
+		desired := &v1alpha2.PolicyPodspecableBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       b.Namespace,
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
+				Annotations:     annotations,
 			},
-			DeciderURI: "http://localhost:8090",
-			AgentSpec:  r.genAgentSpec(b),
-		},
+			Spec: v1alpha2.PolicyPodspecableBindingSpec{
+				BindingSpec: duckv1alpha1.BindingSpec{
+					Subject: s,
+				},
+				DeciderURI: "http://localhost:8090",
+				AgentSpec:  r.genAgentSpec(b),
+			},
+		}
+		pb, err := r.reconcilePodspecableBinding(ctx, desired)
+		if err != nil {
+			return nil, err
+		}
+		pbs = append(pbs, pb)
 	}
+	return pbs, r.deleteStalePodspecableBindings(b, subjects)
+}
+
+// podspecableBindingAnnotations are the annotations of PolicyPodspecableBindings
+// kept in sync with the desired ones.
+var podspecableBindingAnnotations = []string{
+	v1alpha2.PolicyGenerationAnnotationKey,
+	v1alpha2.FallbackAnnotationKey,
+}
+
+func (r *Reconciler) reconcilePodspecableBinding(ctx context.Context, desired *v1alpha2.PolicyPodspecableBinding) (*v1alpha2.PolicyPodspecableBinding, pkgreconciler.Event) {
 	pb, err := r.psbindingLister.PolicyPodspecableBindings(desired.Namespace).Get(desired.Name)
 	if apierrs.IsNotFound(err) {
 		pb, err = r.SecurityClientSet.SecurityV1alpha2().PolicyPodspecableBindings(desired.Namespace).Create(desired)
@@ -180,11 +407,26 @@ func (r *Reconciler) reconcilePodspecableBinding(
 		return nil, fmt.Errorf("Failed to get PolicyPodspecableBinding: %w", err)
 	}
 
-	if !equality.Semantic.DeepDerivative(desired.Spec, pb.Spec) || desired.GetAnnotations()["security.knative.dev/policyGeneration"] != pb.GetAnnotations()["security.knative.dev/policyGeneration"] {
+	annotationsChanged := false
+	for _, k := range podspecableBindingAnnotations {
+		if desired.GetAnnotations()[k] != pb.GetAnnotations()[k] {
+			annotationsChanged = true
+		}
+	}
+	if !equality.Semantic.DeepDerivative(desired.Spec, pb.Spec) || annotationsChanged {
 		// Don't modify the informers copy.
 		cp := pb.DeepCopy()
 		cp.Spec = desired.Spec
-		cp.GetAnnotations()["security.knative.dev/policyGeneration"] = desired.GetAnnotations()["security.knative.dev/policyGeneration"]
+		if cp.Annotations == nil {
+			cp.Annotations = map[string]string{}
+		}
+		for _, k := range podspecableBindingAnnotations {
+			if v := desired.GetAnnotations()[k]; v == "" {
+				delete(cp.Annotations, k)
+			} else {
+				cp.Annotations[k] = v
+			}
+		}
 		pb, err = r.SecurityClientSet.SecurityV1alpha2().PolicyPodspecableBindings(cp.Namespace).Update(cp)
 		if err != nil {
 			return nil, fmt.Errorf("Failed to update PolicyPodspecableBinding: %w", err)
@@ -193,29 +435,138 @@ func (r *Reconciler) reconcilePodspecableBinding(
 	return pb, nil
 }
 
-func policyToRego(spec *v1alpha2.HTTPPolicySpec) string {
+// deleteStalePodspecableBindings deletes the PolicyPodspecableBindings of the
+// binding no longer needed, e.g. after switching between a subject and the
+// namespace workloads.
+func (r *Reconciler) deleteStalePodspecableBindings(b *v1alpha2.HTTPPolicyBinding, subjects map[string]tracker.Reference) error {
+	pbs, err := r.ownedPodspecableBindings(b)
+	if err != nil {
+		return err
+	}
+	for _, pb := range pbs {
+		if _, ok := subjects[pb.Name]; ok || pb.DeletionTimestamp != nil {
+			continue
+		}
+		err := r.SecurityClientSet.SecurityV1alpha2().PolicyPodspecableBindings(pb.Namespace).Delete(pb.Name, &metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("Failed to delete PolicyPodspecableBinding: %w", err)
+		}
+	}
+	return nil
+}
+
+// ownedPodspecableBindings lists the PolicyPodspecableBindings of the binding,
+// including those being deleted.
+func (r *Reconciler) ownedPodspecableBindings(b *v1alpha2.HTTPPolicyBinding) ([]*v1alpha2.PolicyPodspecableBinding, error) {
+	pbs, err := r.psbindingLister.PolicyPodspecableBindings(b.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list PolicyPodspecableBindings: %w", err)
+	}
+	var owned []*v1alpha2.PolicyPodspecableBinding
+	for _, pb := range pbs {
+		if metav1.IsControlledBy(pb, b) {
+			owned = append(owned, pb)
+		}
+	}
+	return owned, nil
+}
+
+// policyGenerations joins the generations of the policies, so that a change to
+// any of them rolls out the workload.
+func policyGenerations(policies []kmeta.OwnerRefable) string {
+	gens := make([]string, 0, len(policies))
+	for _, p := range policies {
+		gens = append(gens, strconv.FormatInt(p.GetObjectMeta().GetGeneration(), 10))
+	}
+	return strings.Join(gens, ",")
+}
+
+func policyToRego(spec *v1alpha2.HTTPPolicySpec, jwks string) (string, error) {
 	pbuilder := opa.NewPolicyBuilder()
-	for _, rule := range spec.Rules {
-		// ignore others for now
-		rbuilder := pbuilder.NewRule()
-		for _, h := range rule.Headers {
-			rbuilder.AppendOneOf(fmt.Sprintf("input.httpRequest.header[%q][_]", h.Key), h.Values)
+	if jwks != "" {
+		jwt := &opa.JWT{
+			Issuer: spec.JWT.Issuer,
+			JWKS:   jwks,
+			Header: spec.JWT.JwtHeader,
+			Prefix: spec.JWT.JwtHeaderPrefix,
 		}
-		for _, op := range rule.Operations {
-			rbuilder.AppendOneOf("input.httpRequest.method", op.Methods)
-			rbuilder.AppendOneOf("input.httpRequest.host", op.Hosts)
-			rbuilder.AppendOneOf("input.httpRequest.path", op.Paths)
+		for _, tr := range spec.JWT.TriggerRules {
+			jwt.TriggerRules = append(jwt.TriggerRules, opa.JWTTriggerRule{
+				IncludePaths: tr.IncludePaths,
+				ExcludePaths: tr.ExcludePaths,
+			})
 		}
+		pbuilder.SetJWT(jwt)
 	}
-	return pbuilder.String()
+
+	for i, rule := range spec.Rules {
+		// Like Istio, a rule matches if any of its sources and any of its
+		// operations match.
+		srcs := rule.From
+		if len(srcs) == 0 {
+			srcs = []v1alpha2.Source{{}}
+		}
+		ops := rule.Operations
+		if len(ops) == 0 {
+			ops = []v1alpha2.Operation{{}}
+		}
+		for _, src := range srcs {
+			for _, op := range ops {
+				appendRule(pbuilder.NewActionRule(fmt.Sprintf("rules[%d]", i), ruleAction(rule.Action)), &rule, &src, &op)
+			}
+		}
+	}
+	if spec.Rego != "" {
+		if err := pbuilder.SetRego(spec.Rego); err != nil {
+			return "", err
+		}
+	}
+	return pbuilder.String(), nil
+}
+
+// appendRule adds the conditions of one source and operation of the rule.
+func appendRule(rbuilder *opa.RuleBuilder, rule *v1alpha2.RuleSpec, src *v1alpha2.Source, op *v1alpha2.Operation) {
+	rbuilder.AppendIPBlocks(src.IPBlocks)
+	rbuilder.AppendSourceNamespaces(src.Namespaces)
+	rbuilder.AppendSourcePrincipals(src.Principals)
+	rbuilder.AppendPrincipals(rule.Auth.Principals)
+	for _, cl := range rule.Auth.Claims {
+		rbuilder.AppendClaim(cl.Key, cl.Values, cl.NotValues)
+	}
+	for _, h := range rule.Headers {
+		header := fmt.Sprintf("input.httpRequest.header[%q][_]", h.Key)
+		rbuilder.AppendOneOf(header, h.Values)
+		rbuilder.AppendNoneOf(header, h.NotValues)
+	}
+	rbuilder.AppendOneOf("input.httpRequest.method", op.Methods)
+	rbuilder.AppendNoneOf("input.httpRequest.method", op.NotMethods)
+	rbuilder.AppendOneOf("input.httpRequest.host", op.Hosts)
+	rbuilder.AppendNoneOf("input.httpRequest.host", op.NotHosts)
+	rbuilder.AppendOneOf("input.httpRequest.path", op.Paths)
+	rbuilder.AppendNoneOf("input.httpRequest.path", op.NotPaths)
+}
+
+func ruleAction(a v1alpha2.RuleAction) opa.Action {
+	switch a {
+	case v1alpha2.RuleActionDeny:
+		return opa.ActionDeny
+	case v1alpha2.RuleActionAudit:
+		return opa.ActionAudit
+	}
+	return opa.ActionAllow
 }
 
 func (r *Reconciler) genAgentSpec(b *v1alpha2.HTTPPolicyBinding) *v1alpha2.PolicyAgentSpec {
 	cfg, _ := logging.NewConfigFromMap(nil)
+	var proxy *v1alpha2.PolicyProxySpec
+	if b.GetAnnotations()[security.EnforcementModeAnnotationKey] == security.EnforcementModeProxy {
+		proxy = &v1alpha2.PolicyProxySpec{Port: proxiedUserPort}
+	}
 	return &v1alpha2.PolicyAgentSpec{
+		Proxy: proxy,
 		Volumes: []corev1.Volume{
 			{
-				Name: "open-policy",
+				Name: policyVolumeName,
 				VolumeSource: corev1.VolumeSource{
 					ConfigMap: &corev1.ConfigMapVolumeSource{
 						LocalObjectReference: corev1.LocalObjectReference{
@@ -237,6 +588,10 @@ func (r *Reconciler) genAgentSpec(b *v1alpha2.HTTPPolicyBinding) *v1alpha2.Polic
 					Name:  "AGENT_PORT",
 					Value: "8090",
 				},
+				{
+					Name:  "EXT_AUTHZ_PORT",
+					Value: "9191",
+				},
 				{
 					Name:  "AGENT_LOGGING_CONFIG",
 					Value: cfg.LoggingConfig,
@@ -249,12 +604,16 @@ func (r *Reconciler) genAgentSpec(b *v1alpha2.HTTPPolicyBinding) *v1alpha2.Polic
 			Ports: []corev1.ContainerPort{
 				{
 					Name:          "http",
-					ContainerPort: 8090,
+					ContainerPort: agentPort,
+				},
+				{
+					Name:          "grpc-ext-authz",
+					ContainerPort: 9191,
 				},
 			},
 			VolumeMounts: []corev1.VolumeMount{
 				{
-					Name:      "open-policy",
+					Name:      policyVolumeName,
 					MountPath: "/var/run/knative/security",
 					ReadOnly:  true,
 				},
@@ -72,6 +72,9 @@ func NewController(
 	policybindinginformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
 	r.WithContext = WithContextFactory(ctx, impl.EnqueueKey)
+	// The bindings track their subjects, so a loser of a conflict is
+	// reconciled again once the winner leaves the subject.
+	r.ListAll = ListAll(ctx, cache.ResourceEventHandlerFuncs{})
 	r.Tracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
 	r.Factory = &duck.CachedInformerFactory{
 		Delegate: &duck.EnqueueInformerFactory{
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package psbinding
+
+import (
+	"fmt"
+	"sort"
+
+	"knative.dev/pkg/apis/duck"
+)
+
+// ConflictDetector is implemented by Bindables that can't always be applied to
+// the same subject along with other Bindables, e.g. because both inject the
+// same container.
+type ConflictDetector interface {
+	// ConflictsWith returns an error describing the conflict if the Bindable
+	// can't be applied along with the other Bindable.
+	ConflictsWith(other duck.Bindable) error
+}
+
+// Fallback is implemented by Bindables that yield to the Bindables they
+// conflict with regardless of age, e.g. defaults for a whole namespace. A
+// fallback is still applied to the subjects it doesn't conflict on.
+type Fallback interface {
+	// IsFallback tells if the Bindable yields to the others.
+	IsFallback() bool
+}
+
+func isFallback(fb Bindable) bool {
+	f, ok := fb.(Fallback)
+	return ok && f.IsFallback()
+}
+
+// conflict records a Bindable not applied because of another Bindable.
+type conflict struct {
+	loser  Bindable
+	winner Bindable
+	err    error
+}
+
+func (c conflict) Error() string {
+	return fmt.Sprintf("conflicts with %s %s/%s: %v",
+		c.winner.GetGroupVersionKind().Kind, c.winner.GetNamespace(), c.winner.GetName(), c.err)
+}
+
+// resolveConflicts orders the Bindables applying to the same subject
+// deterministically and leaves out those conflicting with an older Bindable,
+// or with any non-fallback Bindable for fallbacks. The Bindables being deleted
+// come first so that undoing them doesn't revert the others, and they never
+// conflict.
+func resolveConflicts(fbs []Bindable) ([]Bindable, []conflict) {
+	sorted := append([]Bindable{}, fbs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		lhs, rhs := sorted[i], sorted[j]
+		if ld, rd := lhs.GetDeletionTimestamp() != nil, rhs.GetDeletionTimestamp() != nil; ld != rd {
+			return ld
+		}
+		if lf, rf := isFallback(lhs), isFallback(rhs); lf != rf {
+			return rf
+		}
+		if lt, rt := lhs.GetCreationTimestamp(), rhs.GetCreationTimestamp(); !lt.Equal(&rt) {
+			return lt.Before(&rt)
+		}
+		if lhs.GetNamespace() != rhs.GetNamespace() {
+			return lhs.GetNamespace() < rhs.GetNamespace()
+		}
+		return lhs.GetName() < rhs.GetName()
+	})
+
+	var applied []Bindable
+	var conflicts []conflict
+	for _, fb := range sorted {
+		if fb.GetDeletionTimestamp() != nil {
+			applied = append(applied, fb)
+			continue
+		}
+		if c, ok := conflictWithAny(fb, applied); ok {
+			conflicts = append(conflicts, c)
+			continue
+		}
+		applied = append(applied, fb)
+	}
+	return applied, conflicts
+}
+
+// conflictOn returns the conflict keeping the Bindable from being applied to a
+// subject along with the other Bindables applying to it. Undoing a Bindable
+// being deleted would revert the Bindables it conflicts with.
+func conflictOn(fb Bindable, fbs []Bindable) (conflict, bool) {
+	if fb.GetDeletionTimestamp() != nil {
+		return conflictWithAny(fb, fbs)
+	}
+	_, conflicts := resolveConflicts(fbs)
+	for _, c := range conflicts {
+		if c.loser.GetNamespace() == fb.GetNamespace() && c.loser.GetName() == fb.GetName() {
+			return c, true
+		}
+	}
+	return conflict{}, false
+}
+
+func conflictWithAny(fb Bindable, others []Bindable) (conflict, bool) {
+	cd, ok := fb.(ConflictDetector)
+	if !ok {
+		return conflict{}, false
+	}
+	for _, o := range others {
+		if o.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if err := cd.ConflictsWith(o); err != nil {
+			return conflict{loser: fb, winner: o, err: err}, true
+		}
+	}
+	return conflict{}, false
+}
@@ -24,8 +24,14 @@ import (
 	mwhinformer "knative.dev/pkg/client/injection/kube/informers/admissionregistration/v1beta1/mutatingwebhookconfiguration"
 	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"knative.dev/pkg/apis/duck"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/controller"
@@ -49,6 +55,15 @@ type Bindable interface {
 	Undo(context.Context, *duckv1.WithPod) duck.JSONPatch
 }
 
+// SubjectValidator is implemented by Bindables that can't be applied to some
+// subjects, e.g. because the subject uses a port the Bindable injects. Such
+// subjects are rejected at admission rather than left without the Bindable.
+type SubjectValidator interface {
+	// ValidateSubject returns an error describing why the Bindable can't be
+	// applied to the subject.
+	ValidateSubject(ctx context.Context, ps *duckv1.WithPod) error
+}
+
 // Mutation is the type of the Do/Undo methods.
 type Mutation func(context.Context, *duckv1.WithPod) duck.JSONPatch
 
@@ -94,6 +109,7 @@ func NewAdmissionController(
 		Client:       client,
 		MWHLister:    mwhInformer.Lister(),
 		SecretLister: secretInformer.Lister(),
+		Recorder:     newRecorder(ctx, client, name),
 	}
 	c := controller.NewImpl(wh, logging.FromContext(ctx), name)
 
@@ -119,3 +135,26 @@ func NewAdmissionController(
 
 	return c
 }
+
+// newRecorder returns the event recorder from the context, or creates one. The
+// Bindable types must be registered in the client-go scheme for the events to
+// refer to them.
+func newRecorder(ctx context.Context, client kubernetes.Interface, component string) record.EventRecorder {
+	if recorder := controller.GetEventRecorder(ctx); recorder != nil {
+		return recorder
+	}
+	logger := logging.FromContext(ctx)
+	eventBroadcaster := record.NewBroadcaster()
+	watches := []watch.Interface{
+		eventBroadcaster.StartLogging(logger.Named("event-broadcaster").Infof),
+		eventBroadcaster.StartRecordingToSink(
+			&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")}),
+	}
+	go func() {
+		<-ctx.Done()
+		for _, w := range watches {
+			w.Stop()
+		}
+	}()
+	return eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+}
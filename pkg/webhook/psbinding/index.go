@@ -16,7 +16,12 @@ limitations under the License.
 
 package psbinding
 
-import "k8s.io/apimachinery/pkg/labels"
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
 
 // exactKey is the type for keys that match exactly.
 type exactKey struct {
@@ -28,17 +33,16 @@ type exactKey struct {
 
 // exactMatcher is our reverse index from subjects to the Bindings that apply to
 // them.
-type exactMatcher map[exactKey]Bindable
+type exactMatcher map[exactKey][]Bindable
 
 // Add writes a key into the reverse index.
 func (em exactMatcher) Add(key exactKey, b Bindable) {
-	em[key] = b
+	em[key] = append(em[key], b)
 }
 
-// Get fetches the key from the reverse index, if present.
-func (em exactMatcher) Get(key exactKey) (bindable Bindable, present bool) {
-	b, ok := em[key]
-	return b, ok
+// Get fetches all the Bindables for the key from the reverse index.
+func (em exactMatcher) Get(key exactKey) []Bindable {
+	return em[key]
 }
 
 // inexactKey is the type for keys that match inexactly (via selector)
@@ -68,14 +72,81 @@ func (im inexactMatcher) Add(key inexactKey, selector labels.Selector, b Bindabl
 	im[key] = pl
 }
 
-// Get fetches the key from the reverse index, if present.
-func (im inexactMatcher) Get(key inexactKey, ls labels.Set) (bindable Bindable, present bool) {
-	// Iterate over the list of pairs matched for this GK + namespace and return the first
-	// Bindable that matches our selector.
+// Get fetches all the Bindables for the key whose selector matches the labels.
+func (im inexactMatcher) Get(key inexactKey, ls labels.Set) []Bindable {
+	var bs []Bindable
 	for _, p := range im[key] {
 		if p.selector.Matches(ls) {
-			return p.sb, true
+			bs = append(bs, p.sb)
 		}
 	}
-	return nil, false
+	return bs
+}
+
+// index holds both reverse indices.
+type index struct {
+	exact   exactMatcher
+	inexact inexactMatcher
+}
+
+// newIndex builds the reverse indices from the Bindables and returns the
+// versions seen for each subject GroupKind.
+func newIndex(fbs []Bindable) (*index, map[schema.GroupKind]sets.String, error) {
+	gks := map[schema.GroupKind]sets.String{}
+	idx := &index{
+		exact:   make(exactMatcher, len(fbs)),
+		inexact: make(inexactMatcher, len(fbs)),
+	}
+	for _, fb := range fbs {
+		ref := fb.GetSubject()
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		gk := schema.GroupKind{
+			Group: gv.Group,
+			Kind:  ref.Kind,
+		}
+		set := gks[gk]
+		if set == nil {
+			set = sets.NewString()
+		}
+		set.Insert(gv.Version)
+		gks[gk] = set
+
+		if ref.Name != "" {
+			idx.exact.Add(exactKey{
+				Group:     gk.Group,
+				Kind:      gk.Kind,
+				Namespace: ref.Namespace,
+				Name:      ref.Name,
+			}, fb)
+		} else {
+			selector, err := metav1.LabelSelectorAsSelector(ref.Selector)
+			if err != nil {
+				return nil, nil, err
+			}
+			idx.inexact.Add(inexactKey{
+				Group:     gk.Group,
+				Kind:      gk.Kind,
+				Namespace: ref.Namespace,
+			}, selector, fb)
+		}
+	}
+	return idx, gks, nil
+}
+
+// Get fetches all the Bindables applying to the subject, exact matches first.
+func (idx *index) Get(gk schema.GroupKind, namespace, name string, ls labels.Set) []Bindable {
+	bs := append([]Bindable{}, idx.exact.Get(exactKey{
+		Group:     gk.Group,
+		Kind:      gk.Kind,
+		Namespace: namespace,
+		Name:      name,
+	})...)
+	return append(bs, idx.inexact.Get(inexactKey{
+		Group:     gk.Group,
+		Kind:      gk.Kind,
+		Namespace: namespace,
+	}, ls)...)
 }
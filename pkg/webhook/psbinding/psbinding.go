@@ -28,13 +28,14 @@ import (
 	"github.com/markbates/inflect"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	admissionlisters "k8s.io/client-go/listers/admissionregistration/v1beta1"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
 	"knative.dev/pkg/apis/duck"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/controller"
@@ -65,15 +66,18 @@ type Reconciler struct {
 	SecretLister corelisters.SecretLister
 	ListAll      ListAll
 
+	// Recorder records the conflicts keeping Bindables from being applied
+	// as events on the Bindables.
+	Recorder record.EventRecorder
+
 	// WithContext is a callback that infuses the context supplied to
 	// Do/Undo with additional context to enable them to complete their
 	// respective tasks.
 	WithContext BindableContext
 
-	// lock protects access to exact and inexact
-	lock    sync.RWMutex
-	exact   exactMatcher
-	inexact inexactMatcher
+	// lock protects access to index
+	lock  sync.RWMutex
+	index *index
 }
 
 var _ controller.Reconciler = (*Reconciler)(nil)
@@ -139,53 +143,62 @@ func (ac *Reconciler) Admit(ctx context.Context, request *admissionv1beta1.Admis
 		return webhook.MakeErrorStatus("unable to decode object: %v", err)
 	}
 
-	// Look up the Bindable for this resource.
-	fb := func() Bindable {
+	// Look up all the Bindables for this resource.
+	fbs := func() []Bindable {
 		ac.lock.RLock()
 		defer ac.lock.RUnlock()
-
-		// Always try to find an exact match first.
-		if sb, ok := ac.exact.Get(exactKey{
-			Group:     request.Kind.Group,
-			Kind:      request.Kind.Kind,
-			Namespace: request.Namespace,
-			Name:      orig.Name,
-		}); ok {
-			return sb
-		}
-
-		// Next look for inexact matches.
-		if sb, ok := ac.inexact.Get(inexactKey{
-			Group:     request.Kind.Group,
-			Kind:      request.Kind.Kind,
-			Namespace: request.Namespace,
-		}, labels.Set(orig.Labels)); ok {
-			return sb
+		if ac.index == nil {
+			return nil
 		}
-		return nil
+		return ac.index.Get(schema.GroupKind{
+			Group: request.Kind.Group,
+			Kind:  request.Kind.Kind,
+		}, request.Namespace, orig.Name, labels.Set(orig.Labels))
 	}()
-	if fb == nil {
+	if len(fbs) == 0 {
 		// This doesn't apply!
 		return &admissionv1beta1.AdmissionResponse{Allowed: true}
 	}
 
-	// Callback into the user's code to setup the context with additional
-	// information needed to perform the mutation.
-	if ac.WithContext != nil {
-		var err error
-		ctx, err = ac.WithContext(ctx, fb)
-		if err != nil {
-			return webhook.MakeErrorStatus("unable to setup binding context: %v", err)
+	// Bindables conflicting with older ones are left out, the conflict is
+	// reported in their status by the BaseReconciler, and as an event at
+	// admission.
+	applied, conflicts := resolveConflicts(fbs)
+	for _, c := range conflicts {
+		logging.FromContext(ctx).Warnf("Not applying %s/%s to %s/%s: %v",
+			c.loser.GetNamespace(), c.loser.GetName(), request.Namespace, orig.Name, c)
+		if ac.Recorder != nil {
+			ac.Recorder.Eventf(c.loser, corev1.EventTypeWarning, "BindingConflict",
+				"Not applied to %s %s/%s: %v", request.Kind.Kind, request.Namespace, orig.Name, c)
 		}
 	}
 
-	// Mutate a copy according to the deletion state of the Bindable.
+	// Mutate a copy according to the deletion state of each Bindable.
 	delta := orig.DeepCopy()
 	var patch duck.JSONPatch
-	if fb.GetDeletionTimestamp() != nil {
-		patch = fb.Undo(ctx, delta)
-	} else {
-		patch = fb.Do(ctx, delta)
+	for _, fb := range applied {
+		// Callback into the user's code to setup the context with additional
+		// information needed to perform the mutation.
+		fctx := ctx
+		if ac.WithContext != nil {
+			var err error
+			fctx, err = ac.WithContext(ctx, fb)
+			if err != nil {
+				return webhook.MakeErrorStatus("unable to setup binding context: %v", err)
+			}
+		}
+
+		if fb.GetDeletionTimestamp() != nil {
+			patch = append(patch, fb.Undo(fctx, delta)...)
+			continue
+		}
+		if sv, ok := fb.(SubjectValidator); ok {
+			if err := sv.ValidateSubject(fctx, delta); err != nil {
+				return webhook.MakeErrorStatus("%s %s/%s can't be applied: %v",
+					fb.GetGroupVersionKind().Kind, fb.GetNamespace(), fb.GetName(), err)
+			}
+		}
+		patch = append(patch, fb.Do(fctx, delta)...)
 	}
 
 	// Synthesize a patch from the changes and return it in our AdmissionResponse
@@ -204,60 +217,23 @@ func (ac *Reconciler) Admit(ctx context.Context, request *admissionv1beta1.Admis
 }
 
 func (ac *Reconciler) reconcileMutatingWebhook(ctx context.Context, caCert []byte) error {
-	// Build a deduplicated list of all of the GVKs we see.
-	gks := map[schema.GroupKind]sets.String{}
-
 	// When reconciling the webhook, enumerate all of the bindings, so that
 	// we can index them to efficiently respond to webhook requests.
 	fbs, err := ac.ListAll()
 	if err != nil {
 		return err
 	}
-	exact := make(exactMatcher, len(fbs))
-	inexact := make(inexactMatcher, len(fbs))
-	for _, fb := range fbs {
-		ref := fb.GetSubject()
-		gv, err := schema.ParseGroupVersion(ref.APIVersion)
-		if err != nil {
-			return err
-		}
-		gk := schema.GroupKind{
-			Group: gv.Group,
-			Kind:  ref.Kind,
-		}
-		set := gks[gk]
-		if set == nil {
-			set = sets.NewString()
-		}
-		set.Insert(gv.Version)
-		gks[gk] = set
-
-		if ref.Name != "" {
-			exact.Add(exactKey{
-				Group:     gk.Group,
-				Kind:      gk.Kind,
-				Namespace: ref.Namespace,
-				Name:      ref.Name,
-			}, fb)
-		} else {
-			selector, err := metav1.LabelSelectorAsSelector(ref.Selector)
-			if err != nil {
-				return err
-			}
-			inexact.Add(inexactKey{
-				Group:     gk.Group,
-				Kind:      gk.Kind,
-				Namespace: ref.Namespace,
-			}, selector, fb)
-		}
+	// Build a deduplicated list of all of the GVKs we see along with the index.
+	idx, gks, err := newIndex(fbs)
+	if err != nil {
+		return err
 	}
 
 	// Update our indices
 	func() {
 		ac.lock.Lock()
 		defer ac.lock.Unlock()
-		ac.exact = exact
-		ac.inexact = inexact
+		ac.index = idx
 	}()
 
 	var rules []admissionregistrationv1beta1.RuleWithOperations
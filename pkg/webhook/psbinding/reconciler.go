@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -75,6 +76,11 @@ type BaseReconciler struct {
 	// Recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	Recorder record.EventRecorder
+
+	// ListAll optionally enumerates all of the Bindables, so that a Bindable
+	// conflicting with another one on the same subject isn't applied and
+	// reports the conflict instead.
+	ListAll ListAll
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -285,6 +291,14 @@ func (r *BaseReconciler) ReconcileSubject(ctx context.Context, fb Bindable, muta
 		}
 	}
 
+	// Don't fight over the referents with the Bindables this one conflicts
+	// with, the webhook doesn't apply it either.
+	referents, err = r.checkConflicts(fb, gv.WithKind(subject.Kind).GroupKind(), referents)
+	if err != nil {
+		fb.GetBindingStatus().MarkBindingUnavailable("BindingConflict", err.Error())
+		return err
+	}
+
 	// Callback into the user's code to setup the context with additional
 	// information needed to perform the mutation.
 	if r.WithContext != nil {
@@ -339,6 +353,38 @@ func (r *BaseReconciler) ReconcileSubject(ctx context.Context, fb Bindable, muta
 	return nil
 }
 
+// checkConflicts returns the referents the Bindable is to be applied to. It
+// returns an error if the Bindable conflicts with another Bindable applying to
+// any of the referents, unless the Bindable is a fallback, which is just not
+// applied to them. A Bindable being deleted isn't undone on them.
+func (r *BaseReconciler) checkConflicts(fb Bindable, gk schema.GroupKind, referents []*duckv1.WithPod) ([]*duckv1.WithPod, error) {
+	if r.ListAll == nil {
+		return referents, nil
+	}
+	if _, ok := fb.(ConflictDetector); !ok {
+		return referents, nil
+	}
+	fbs, err := r.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	idx, _, err := newIndex(fbs)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*duckv1.WithPod
+	for _, ps := range referents {
+		c, ok := conflictOn(fb, idx.Get(gk, ps.Namespace, ps.Name, labels.Set(ps.Labels)))
+		switch {
+		case !ok:
+			ret = append(ret, ps)
+		case fb.GetDeletionTimestamp() == nil && !isFallback(fb):
+			return nil, fmt.Errorf("binding %s/%s %v", ps.Namespace, ps.Name, c)
+		}
+	}
+	return ret, nil
+}
+
 // UpdateStatus updates the status of the resource.  Caller is responsible for
 // checking for semantic differences before calling.
 func (r *BaseReconciler) UpdateStatus(ctx context.Context, desired Bindable) error {